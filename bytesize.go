@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// shortUnitBase is the base of the single letter suffixes K, M, G and T. It
+// is 1024 unless configured otherwise with --unit-base.
+var shortUnitBase int64 = 1024
+
+// setUnitBase configures the base of the single letter suffixes from the
+// --unit-base flag.
+func setUnitBase(c *cli.Context) error {
+	switch c.Int("unit-base") {
+	case 1024, 1000:
+		shortUnitBase = int64(c.Int("unit-base"))
+		return nil
+	default:
+		return fmt.Errorf("unit base must be 1024 or 1000")
+	}
+}
+
+// byteSizeSuffix is a size suffix and the exponent of its base.
+type byteSizeSuffix struct {
+	suffix   string
+	base     int64
+	exponent int
+}
+
+// byteSizeSuffixes lists the supported size suffixes. The IEC suffixes KiB,
+// MiB, GiB and TiB are binary, the SI suffixes KB, MB, GB and TB decimal.
+// Longer suffixes are listed first so that they take precedence.
+var byteSizeSuffixes = []byteSizeSuffix{
+	{"KIB", 1024, 1},
+	{"MIB", 1024, 2},
+	{"GIB", 1024, 3},
+	{"TIB", 1024, 4},
+	{"KB", 1000, 1},
+	{"MB", 1000, 2},
+	{"GB", 1000, 3},
+	{"TB", 1000, 4},
+	{"K", 0, 1},
+	{"M", 0, 2},
+	{"G", 0, 3},
+	{"T", 0, 4},
+}
+
+// multiplier returns the number of bytes the suffix stands for.
+func (s byteSizeSuffix) multiplier() int64 {
+	base := s.base
+	if base == 0 {
+		base = shortUnitBase
+	}
+	multiplier := int64(1)
+	for i := 0; i < s.exponent; i++ {
+		multiplier *= base
+	}
+	return multiplier
+}
+
+// parseByteSize parses a number of bytes with an optional size suffix like
+// "4MiB" or "0x10KB". The number itself accepts the prefixes understood by
+// strconv.ParseInt with base 0.
+func parseByteSize(s string) (int64, error) {
+	number := strings.TrimSpace(s)
+	multiplier := int64(1)
+	upper := strings.ToUpper(number)
+	for _, suffix := range byteSizeSuffixes {
+		if strings.HasSuffix(upper, suffix.suffix) {
+			number = strings.TrimSpace(number[:len(number)-len(suffix.suffix)])
+			multiplier = suffix.multiplier()
+			break
+		}
+	}
+
+	value, err := strconv.ParseInt(number, 0, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value > math.MaxInt64/multiplier || value < math.MinInt64/multiplier {
+		return 0, fmt.Errorf("size \"%s\" is out of range", s)
+	}
+	return value * multiplier, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// diffCols is the number of bytes per row of the side-by-side dump.
+const diffCols = 16
+
+// diffInput is one side of a comparison.
+type diffInput struct {
+	name string
+	s    section
+	// row and prev are the current and the previous row read from s.
+	row, prev []byte
+	eof       bool
+}
+
+func openDiffInput(name string, sel selection) (*diffInput, error) {
+	file := os.Stdin
+	if name != "-" {
+		var err error
+		file, err = os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not open file, reason: %w", err)
+		}
+	}
+	sections, err := sel.sections(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) != 1 {
+		return nil, fmt.Errorf("diff compares a single range of every file")
+	}
+	return &diffInput{name: name, s: sections[0]}, nil
+}
+
+// next reads the next row.
+func (d *diffInput) next() error {
+	d.prev = d.row
+	if d.eof {
+		d.row = nil
+		return nil
+	}
+	row := make([]byte, diffCols)
+	n, err := io.ReadFull(d.s.r, row)
+	d.row = row[:n]
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		d.eof = true
+		return nil
+	}
+	return err
+}
+
+// writeDiffRow writes the rows a and b at position pos of the slices side by
+// side, marking the row with '>' if they differ.
+func writeDiffRow(out io.Writer, pos int64, a, b *diffInput, rowA, rowB []byte) {
+	hexRow := func(row []byte) string {
+		var line strings.Builder
+		for i := 0; i < diffCols; i++ {
+			if i < len(row) {
+				fmt.Fprintf(&line, "%02x ", row[i])
+			} else {
+				line.WriteString("   ")
+			}
+		}
+		return line.String()
+	}
+	mark := ' '
+	if !bytes.Equal(rowA, rowB) {
+		mark = '>'
+	}
+	line := fmt.Sprintf("%c %08x  %s  %08x  %s", mark, a.s.offset+pos, hexRow(rowA), b.s.offset+pos, hexRow(rowB))
+	fmt.Fprintln(out, strings.TrimRight(line, " "))
+}
+
+// diff compares the selections of the files called nameA and nameB. If they
+// differ, it writes the first difference along with a side-by-side hex dump
+// around it to out and reports false.
+func diff(out io.Writer, sel selection, nameA, nameB string) (bool, error) {
+	a, err := openDiffInput(nameA, sel)
+	if err != nil {
+		return false, err
+	}
+	b, err := openDiffInput(nameB, sel)
+	if err != nil {
+		return false, err
+	}
+
+	var pos int64
+	for {
+		err = a.next()
+		if err == nil {
+			err = b.next()
+		}
+		if err != nil {
+			return false, fmt.Errorf("an error occured during reading of the file: %w", err)
+		}
+		if bytes.Equal(a.row, b.row) {
+			if len(a.row) < diffCols {
+				return true, nil
+			}
+			pos += diffCols
+			continue
+		}
+		break
+	}
+
+	i := 0
+	for i < len(a.row) && i < len(b.row) && a.row[i] == b.row[i] {
+		i++
+	}
+	if i == len(a.row) || i == len(b.row) {
+		shorter := a
+		if len(b.row) < len(a.row) {
+			shorter = b
+		}
+		fmt.Fprintf(out, "%s ends after 0x%X bytes\n", shorter.name, pos+int64(i))
+	} else {
+		fmt.Fprintf(out, "slices differ at 0x%X: %s 0x%X is 0x%02x, %s 0x%X is 0x%02x\n",
+			pos+int64(i), a.name, a.s.offset+pos+int64(i), a.row[i], b.name, b.s.offset+pos+int64(i), b.row[i])
+	}
+
+	if len(a.prev) > 0 {
+		writeDiffRow(out, pos-diffCols, a, b, a.prev, b.prev)
+	}
+	writeDiffRow(out, pos, a, b, a.row, b.row)
+	err = a.next()
+	if err == nil {
+		err = b.next()
+	}
+	if err != nil {
+		return false, fmt.Errorf("an error occured during reading of the file: %w", err)
+	}
+	if len(a.row) > 0 || len(b.row) > 0 {
+		writeDiffRow(out, pos+diffCols, a, b, a.row, b.row)
+	}
+	return false, nil
+}
+
+// diffCommand compares the selection of two files.
+func diffCommand(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("diff requires exactly two files")
+	}
+	if err := setUnitBase(c); err != nil {
+		return err
+	}
+	sel, err := parseSelection(c)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	equal, err := diff(out, sel, c.Args().Get(0), c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	if !equal {
+		out.Flush()
+		return cli.Exit("the slices differ", 1)
+	}
+	return out.Flush()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// explainRange describes the range r of the file called name.
+func explainRange(out io.Writer, name string, file *os.File, r byteRange) {
+	fmt.Fprintf(out, "%s: offset 0x%X (%d), ", name, r.offset, r.offset)
+	length := knownLength(file, r)
+	switch {
+	case r.size == -1 && length >= 0:
+		fmt.Fprintf(out, "to end of file (0x%X bytes)\n", length)
+	case r.size == -1:
+		fmt.Fprintln(out, "to end of input")
+	case length >= 0 && length < r.size:
+		fmt.Fprintf(out, "size 0x%X (%d), truncated to 0x%X bytes\n", r.size, r.size, length)
+	default:
+		fmt.Fprintf(out, "size 0x%X (%d)\n", r.size, r.size)
+	}
+}
+
+// explain writes the ranges that sel selects from the files called
+// filenames to out, without reading any data.
+func explain(out io.Writer, sel selection, filenames []string) error {
+	for _, filename := range filenames {
+		if sel.tarMember != "" {
+			fmt.Fprintf(out, "%s: tar member \"%s\", offset 0x%X (%d) relative to the member\n", filename, sel.tarMember, sel.offset, sel.offset)
+			continue
+		}
+
+		if isURL(filename) {
+			fmt.Fprintf(out, "%s: offset 0x%X (%d), requested via HTTP\n", filename, sel.offset, sel.offset)
+			continue
+		}
+
+		file := os.Stdin
+		if filename != "-" {
+			var err error
+			file, err = os.Open(filename)
+			if err != nil {
+				return fmt.Errorf("could not open file, reason: %w", err)
+			}
+			defer file.Close()
+		}
+		ranges, err := sel.resolve(file)
+		if err != nil {
+			return err
+		}
+		for _, r := range ranges {
+			explainRange(out, filename, file, r)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// followInterval is the time between two checks for appended data.
+const followInterval = 250 * time.Millisecond
+
+// followReader reads file like tail -f does. At the end of file it waits for
+// more data to be appended instead of returning io.EOF.
+type followReader struct {
+	file *os.File
+	// pos is the current position within file.
+	pos int64
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		f.pos += int64(n)
+		if err == io.EOF && n > 0 {
+			return n, nil
+		}
+		if err != io.EOF {
+			return n, err
+		}
+
+		info, err := f.file.Stat()
+		if err != nil {
+			return 0, err
+		}
+		if info.Size() < f.pos {
+			fmt.Fprintf(os.Stderr, "WARNING: file truncated to 0x%X bytes, following it from the start\n", info.Size())
+			f.pos, err = f.file.Seek(0, io.SeekStart)
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+		time.Sleep(followInterval)
+	}
+}
+
+// flushWriter flushes w after every write, so that followed data shows up
+// as soon as it has been formatted.
+type flushWriter struct {
+	w *bufio.Writer
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, f.w.Flush()
+}
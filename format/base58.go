@@ -0,0 +1,62 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// base58Alphabet is the alphabet used by Bitcoin.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// maxBase58Input limits the input of the base58 format, as encoding takes
+// quadratic time in the input size.
+const maxBase58Input = 1 << 20
+
+// encodeBase58 encodes data using the Bitcoin alphabet. Every leading zero
+// byte is encoded as a leading '1'.
+func encodeBase58(data []byte) []byte {
+	var zeros int
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	// digits holds the base 58 representation of data in little endian.
+	// log(256) / log(58) < 1.37, so this is large enough.
+	digits := make([]byte, 0, (len(data)-zeros)*137/100+1)
+	for _, b := range data[zeros:] {
+		carry := int(b)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+
+	encoded := make([]byte, zeros+len(digits))
+	for i := 0; i < zeros; i++ {
+		encoded[i] = base58Alphabet[0]
+	}
+	for i, d := range digits {
+		encoded[len(encoded)-1-i] = base58Alphabet[d]
+	}
+	return encoded
+}
+
+// writeBase58 writes in encoded in base58. Base58 cannot be streamed, so the
+// whole input is buffered and limited to maxBase58Input bytes.
+func writeBase58(out io.Writer, in io.Reader) error {
+	data, err := ioutil.ReadAll(io.LimitReader(in, maxBase58Input+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxBase58Input {
+		return fmt.Errorf("base58 input is limited to %d bytes", maxBase58Input)
+	}
+	out.Write(encodeBase58(data))
+	return nil
+}
@@ -0,0 +1,50 @@
+package format
+
+const (
+	// ansiReset is the ANSI escape sequence resetting all attributes.
+	ansiReset = "\x1b[0m"
+	// ansiReverse is the ANSI code highlighting by swapping the foreground
+	// and background colors.
+	ansiReverse = "7"
+)
+
+// byteClassColor returns the ANSI color code of the class of b, similar to
+// hexyl.
+func byteClassColor(b byte) string {
+	switch {
+	case b == 0:
+		// bright black
+		return "90"
+	case b == ' ' || '\t' <= b && b <= '\r':
+		// green
+		return "32"
+	case 0x20 < b && b < 0x7f:
+		// cyan
+		return "36"
+	case b < 0x80:
+		// magenta
+		return "35"
+	default:
+		// yellow
+		return "33"
+	}
+}
+
+// highlighted reports whether the byte at pos is within the highlighted
+// range.
+func (o Options) highlighted(pos int64) bool {
+	return o.HighlightSize > 0 && o.HighlightOffset <= pos && pos < o.HighlightOffset+o.HighlightSize
+}
+
+// colorize returns s, the representation of the byte b at pos, wrapped in the
+// escape sequences it is colored with if colors are enabled.
+func (o Options) colorize(s string, b byte, pos int64) string {
+	if !o.Color {
+		return s
+	}
+	code := byteClassColor(b)
+	if o.highlighted(pos) {
+		code = ansiReverse + ";" + code
+	}
+	return "\x1b[" + code + "m" + s + ansiReset
+}
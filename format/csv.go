@@ -0,0 +1,42 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeCSV writes the bytes of in as comma separated values with cols values
+// per row, in decimal unless hexValues is set.
+func writeCSV(out io.Writer, in io.Reader, cols int, hexValues bool) error {
+	valueFormat := "%d"
+	if hexValues {
+		valueFormat = "%02x"
+		if opts.Upper {
+			valueFormat = "%02X"
+		}
+	}
+
+	// The values never need quoting, so the records are joined directly
+	// instead of using encoding/csv, which terminates every record.
+	lines := &lineWriter{out: out}
+	row := make([]byte, cols)
+	record := make([]string, 0, cols)
+	for {
+		n, err := io.ReadFull(in, row)
+		if n > 0 {
+			record = record[:0]
+			for _, b := range row[:n] {
+				record = append(record, fmt.Sprintf(valueFormat, b))
+			}
+			lines.line(strings.Join(record, ","))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package format
+
+import (
+	"compress/gzip"
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+)
+
+// decoders maps the names of formats to formatters inverting them.
+var decoders = map[string]Formatter{
+	"hex":  decodeHex,
+	"dump": decodeHexDump,
+	"xxd":  decodeHexDump,
+	"base64": func(out io.Writer, in io.Reader) error {
+		return decodeBase64(out, in, base64.StdEncoding)
+	},
+	"base64url": func(out io.Writer, in io.Reader) error {
+		return decodeBase64(out, in, base64.URLEncoding)
+	},
+	"base32": func(out io.Writer, in io.Reader) error {
+		encoding := base32.StdEncoding
+		if opts.NoPadding {
+			encoding = encoding.WithPadding(base32.NoPadding)
+		}
+		_, err := io.Copy(out, base32.NewDecoder(encoding, whitespaceSkipper{in}))
+		return err
+	},
+	"ascii85": func(out io.Writer, in io.Reader) error {
+		_, err := io.Copy(out, ascii85.NewDecoder(whitespaceSkipper{in}))
+		return err
+	},
+	"gzip": func(out io.Writer, in io.Reader) error {
+		zr, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, zr)
+		if err != nil {
+			return err
+		}
+		return zr.Close()
+	},
+}
+
+// whitespaceSkipper drops all whitespace read through it.
+type whitespaceSkipper struct {
+	r io.Reader
+}
+
+func (w whitespaceSkipper) Read(p []byte) (int, error) {
+	for {
+		n, err := w.r.Read(p)
+		kept := 0
+		for _, b := range p[:n] {
+			switch b {
+			case ' ', '\t', '\n', '\r', '\v', '\f':
+			default:
+				p[kept] = b
+				kept++
+			}
+		}
+		// Do not report zero bytes read just because all were whitespace.
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// decodeHex reads hex text, ignoring any whitespace, and writes the decoded
+// bytes.
+func decodeHex(out io.Writer, in io.Reader) error {
+	_, err := io.Copy(out, hex.NewDecoder(whitespaceSkipper{in}))
+	return err
+}
+
+// decodeBase64 reads base64 text, ignoring any whitespace, and writes the
+// decoded bytes.
+func decodeBase64(out io.Writer, in io.Reader, encoding *base64.Encoding) error {
+	if opts.NoPadding {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	_, err := io.Copy(out, base64.NewDecoder(encoding, whitespaceSkipper{in}))
+	return err
+}
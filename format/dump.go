@@ -0,0 +1,60 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dumpLine returns a single line in the layout of hex.Dumper.
+func dumpLine(offset int64, row []byte, cols int) string {
+	var line strings.Builder
+	fmt.Fprintf(&line, opts.offsetFormat()+"  ", offset)
+
+	for i := 0; i < cols; i++ {
+		if i < len(row) {
+			line.WriteString(opts.colorize(fmt.Sprintf("%02x", row[i]), row[i], offset+int64(i)))
+			line.WriteByte(' ')
+		} else {
+			line.WriteString("   ")
+		}
+		// Bytes are grouped by eight with an additional space.
+		if (i+1)%8 == 0 && i != cols-1 {
+			line.WriteByte(' ')
+		}
+	}
+	if opts.NoASCII {
+		return strings.TrimRight(line.String(), " ")
+	}
+	line.WriteString(" |")
+
+	for i, b := range row {
+		char := b
+		if !(32 <= b && b <= 126) {
+			char = '.'
+		}
+		line.WriteString(opts.colorize(string(char), b, offset+int64(i)))
+	}
+	line.WriteString("|")
+	return line.String()
+}
+
+// writeDump writes in as a hex dump in the layout of hex.Dumper, but with cols
+// bytes per line and labelling the first line with offset.
+func writeDump(out io.Writer, in io.Reader, offset int64, cols int) error {
+	lines := &lineWriter{out: out}
+	row := make([]byte, cols)
+	for {
+		n, err := io.ReadFull(in, row)
+		if n > 0 {
+			lines.line(dumpLine(offset, row[:n], cols))
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
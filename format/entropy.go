@@ -0,0 +1,62 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// shannonEntropy computes the entropy in bits per byte of data with the
+// given byte frequencies.
+func shannonEntropy(counts *[256]uint64, total uint64) float64 {
+	var entropy float64
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// writeEntropy writes the Shannon entropy of in. If window is positive, the
+// entropy of every window bytes is written on its own line, prefixed by the
+// offset of the window.
+func writeEntropy(out io.Writer, in io.Reader, offset int64, window int) error {
+	lines := &lineWriter{out: out}
+	var counts [256]uint64
+	var total uint64
+	windowStart := offset
+	flush := func() {
+		lines.printf(opts.offsetFormat()+"  %.6f", windowStart, shannonEntropy(&counts, total))
+		windowStart += int64(total)
+		counts = [256]uint64{}
+		total = 0
+	}
+
+	var err error
+	var n int
+	buf := make([]byte, opts.BufferSize)
+	for err == nil {
+		n, err = in.Read(buf)
+
+		for _, b := range buf[:n] {
+			counts[b]++
+			total++
+			if window > 0 && total == uint64(window) {
+				flush()
+			}
+		}
+	}
+	if err != io.EOF {
+		return err
+	}
+
+	if window <= 0 {
+		fmt.Fprintf(out, "%.6f", shannonEntropy(&counts, total))
+	} else if total > 0 {
+		flush()
+	}
+	return nil
+}
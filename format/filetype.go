@@ -0,0 +1,70 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// sniffLen is the number of bytes the filetype format looks at.
+const sniffLen = 512
+
+// magic is a byte sequence identifying a file type at a fixed offset.
+type magic struct {
+	offset int
+	bytes  []byte
+	label  string
+}
+
+// magics are checked in order before falling back to
+// http.DetectContentType.
+var magics = []magic{
+	{0, []byte("\x7fELF"), "ELF"},
+	{0, []byte("MZ"), "PE/DOS executable"},
+	{0, []byte("\xfe\xed\xfa\xce"), "Mach-O 32-bit"},
+	{0, []byte("\xce\xfa\xed\xfe"), "Mach-O 32-bit"},
+	{0, []byte("\xfe\xed\xfa\xcf"), "Mach-O 64-bit"},
+	{0, []byte("\xcf\xfa\xed\xfe"), "Mach-O 64-bit"},
+	{0, []byte("\xca\xfe\xba\xbe"), "Mach-O universal or Java class"},
+	{0, []byte("\x00asm"), "WebAssembly"},
+	{0, []byte("PK\x03\x04"), "ZIP"},
+	{0, []byte("PK\x05\x06"), "ZIP (empty)"},
+	{0, []byte("\x1f\x8b"), "gzip"},
+	{0, []byte("BZh"), "bzip2"},
+	{0, []byte("\xfd7zXZ\x00"), "xz"},
+	{0, []byte("\x28\xb5\x2f\xfd"), "zstd"},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), "7-Zip"},
+	{0, []byte("Rar!\x1a\x07"), "RAR"},
+	{257, []byte("ustar"), "tar"},
+	{0, []byte("\x89PNG\r\n\x1a\n"), "PNG"},
+	{0, []byte("GIF87a"), "GIF"},
+	{0, []byte("GIF89a"), "GIF"},
+	{0, []byte("\xff\xd8\xff"), "JPEG"},
+	{0, []byte("%PDF-"), "PDF"},
+	{0, []byte("SQLite format 3\x00"), "SQLite 3 database"},
+}
+
+// writeFileType writes a guess of the type of the data read from in, based on
+// its first sniffLen bytes.
+func writeFileType(out io.Writer, in io.Reader) error {
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(in, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	data := buf[:n]
+
+	if len(data) == 0 {
+		fmt.Fprint(out, "empty")
+		return nil
+	}
+	for _, m := range magics {
+		if len(data) >= m.offset+len(m.bytes) && bytes.Equal(data[m.offset:m.offset+len(m.bytes)], m.bytes) {
+			fmt.Fprint(out, m.label)
+			return nil
+		}
+	}
+	fmt.Fprint(out, http.DetectContentType(data))
+	return nil
+}
@@ -0,0 +1,254 @@
+// Package format implements the output formats of slice. Every format turns
+// the bytes read from an io.Reader into some representation written to an
+// io.Writer.
+package format
+
+import (
+	"fmt"
+	"io"
+)
+
+// Formatter writes the bytes read from in to out in some format. Text formats
+// leave out the trailing newline, which Format adds.
+type Formatter func(out io.Writer, in io.Reader) error
+
+// Options holds settings that formatters may depend on.
+type Options struct {
+	// BufferSize is the number of bytes streaming formats read at once.
+	BufferSize int
+	// HashSize is the digest size in bytes of variable length hashes.
+	HashSize int
+	// HMACKey makes hash formats that support it compute an HMAC with this
+	// key instead of a plain digest, if it is not nil.
+	HMACKey []byte
+	// HashTruncate is the number of hex characters digests are truncated to,
+	// zero keeps the whole digest.
+	HashTruncate int
+	// NoPadding omits the padding characters of base32 and base64 encodings.
+	NoPadding bool
+	// Spec describes the fields read by the struct format as comma separated
+	// TYPE:NAME pairs.
+	Spec string
+	// CSVHex makes the csv format write hex instead of decimal values.
+	CSVHex bool
+	// YAMLBinary makes the yaml format emit a base64 encoded !!binary scalar
+	// instead of a sequence.
+	YAMLBinary bool
+	// ZeroPad fills up the input of block based encodings (z85) with zero
+	// bytes instead of rejecting incomplete blocks.
+	ZeroPad bool
+	// Width is the number of bytes per line of line based formats, zero
+	// selects the default of the respective format.
+	Width int
+	// FixedSize makes language literal formats emit fixed size arrays
+	// instead of slices.
+	FixedSize bool
+	// WithLength makes language literal formats emit a length constant.
+	WithLength bool
+	// VarName is the identifier emitted by language literal formats, empty
+	// selects the default of the respective format.
+	VarName string
+	// AddressBits is the width of addresses in record based formats.
+	AddressBits int
+	// Group is the number of bytes after which the hex format inserts Sep,
+	// zero disables grouping.
+	Group int
+	Sep   string
+	// Color enables ANSI colors in hex dumps.
+	Color bool
+	// HighlightOffset and HighlightSize are the range of input positions
+	// that hex dumps highlight if Color is set.
+	HighlightOffset int64
+	HighlightSize   int64
+	// NoASCII omits the ASCII column of hex dumps.
+	NoASCII bool
+	// DecimalOffsets prints the offset column of hex dumps in decimal.
+	DecimalOffsets bool
+	// MinLen is the minimum length of strings extracted by the strings format.
+	MinLen int
+	// WithOffset prefixes extracted strings with their offset.
+	WithOffset bool
+	// OffsetDigits is the minimum number of hex digits of offsets written by
+	// the offsets format.
+	OffsetDigits int
+	// Char adds the ASCII character of every byte to the offsets format.
+	Char bool
+	// Repair makes the utf8check format output the input with invalid UTF-8
+	// replaced instead of the result of the check.
+	Repair bool
+	// Window is the number of bytes per entropy value, zero computes a single
+	// value for the whole input.
+	Window int
+	// Top limits the histogram to the most frequent byte values, zero shows
+	// all of them.
+	Top int
+	// BigEndian selects the byte order of multi-byte input (utf16).
+	BigEndian bool
+	// KeepUnreserved leaves characters that need no escaping in URLs as they
+	// are (urlencode).
+	KeepUnreserved bool
+	// Upper makes hex based formats emit uppercase digits.
+	Upper bool
+	// NoNewline drops the trailing newline of text formats.
+	NoNewline bool
+	// Prefix and Suffix are written around the output of text formats, in
+	// front of the trailing newline.
+	Prefix string
+	Suffix string
+	// Offset is the position of the first input byte within its file.
+	Offset int64
+}
+
+// DefaultOptions returns the options used unless SetOptions is called.
+func DefaultOptions() Options {
+	return Options{
+		BufferSize:   64 * 1024,
+		HashSize:     64,
+		AddressBits:  32,
+		Sep:          " ",
+		MinLen:       4,
+		OffsetDigits: 4,
+	}
+}
+
+var opts = DefaultOptions()
+
+// SetOptions replaces the options used by all formats.
+func SetOptions(o Options) {
+	opts = o
+}
+
+// lineWidth returns the configured number of bytes per line or def if none
+// was configured.
+func (o Options) lineWidth(def int) int {
+	if o.Width > 0 {
+		return o.Width
+	}
+	return def
+}
+
+// varName returns the configured identifier or def if none was configured.
+func (o Options) varName(def string) string {
+	if o.VarName != "" {
+		return o.VarName
+	}
+	return def
+}
+
+// offsetFormat returns the format of the offset column of hex dumps.
+func (o Options) offsetFormat() string {
+	if o.DecimalOffsets {
+		return "%08d"
+	}
+	return "%08x"
+}
+
+// FormatInfo describes an output format.
+type FormatInfo struct {
+	Name        string
+	Description string
+	// Digest formats output a fixed size checksum of their input.
+	Digest bool
+	// hmac formats can compute an HMAC, see Options.HMACKey.
+	hmac bool
+	// hidden formats work but are not advertised.
+	hidden bool
+	// binary formats output bytes rather than text, their output is never
+	// altered.
+	binary bool
+	// verbatim reports whether the format outputs text taken from the input
+	// as is, which Format does not end with a newline. It may be nil.
+	verbatim  func() bool
+	formatter Formatter
+}
+
+// Format writes in to out in the format f. The output of text formats is
+// surrounded by Options.Prefix and Options.Suffix and ends with a newline
+// unless Options.NoNewline is set or the format outputs text verbatim.
+func (f FormatInfo) Format(out io.Writer, in io.Reader) error {
+	if f.binary {
+		return f.formatter(out, in)
+	}
+
+	io.WriteString(out, opts.Prefix)
+	counter := &countingWriter{w: out}
+	err := f.formatter(counter, in)
+	if err != nil {
+		return err
+	}
+	io.WriteString(out, opts.Suffix)
+	// Formats that output nothing, like dump for empty input, do not
+	// output an empty line either.
+	wroteAny := counter.n > 0 || opts.Prefix != "" || opts.Suffix != ""
+	if wroteAny && !opts.NoNewline && (f.verbatim == nil || !f.verbatim()) {
+		out.Write([]byte{'\n'})
+	}
+	return nil
+}
+
+// CanDecode reports whether the format f can be decoded.
+func (f FormatInfo) CanDecode() bool {
+	_, ok := decoders[f.Name]
+	return ok
+}
+
+// SupportsHMAC reports whether the format f computes an HMAC if
+// Options.HMACKey is set.
+func (f FormatInfo) SupportsHMAC() bool {
+	return f.hmac
+}
+
+// Decode reads input in the format f from in and writes the decoded bytes to
+// out.
+func (f FormatInfo) Decode(out io.Writer, in io.Reader) error {
+	decoder, ok := decoders[f.Name]
+	if !ok {
+		return fmt.Errorf("the format \"%s\" cannot be decoded", f.Name)
+	}
+	return decoder(out, in)
+}
+
+// Lookup returns the format called name.
+func Lookup(name string) (FormatInfo, bool) {
+	for _, info := range formats {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return FormatInfo{}, false
+}
+
+// Formats returns all advertised formats.
+func Formats() []FormatInfo {
+	var infos []FormatInfo
+	for _, info := range formats {
+		if !info.hidden {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// RegisterFormatter adds the format name implemented by f to the registry,
+// making it available to Format, Lookup and Formats. It returns an error if a
+// format with that name exists already. RegisterFormatter is not safe for
+// concurrent use with other functions of this package.
+func RegisterFormatter(name string, f Formatter) error {
+	if _, ok := Lookup(name); ok {
+		return fmt.Errorf("a format called \"%s\" is registered already", name)
+	}
+	formats = append(formats, FormatInfo{
+		Name:      name,
+		formatter: f,
+	})
+	return nil
+}
+
+// Format writes in to out in the format called name.
+func Format(name string, out io.Writer, in io.Reader) error {
+	info, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("unsupported format \"%s\"", name)
+	}
+	return info.Format(out, in)
+}
@@ -0,0 +1,888 @@
+package format
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"io/ioutil"
+	"mime/quotedprintable"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+func makeCPrintSafe(b byte) (string, bool) {
+	escaped, ok := map[byte]string{
+		'\a': "\\a",
+		'\b': "\\b",
+		'\f': "\\f",
+		'\n': "\\n",
+		'\r': "\\r",
+		'\t': "\\t",
+		'\v': "\\v",
+		'\\': "\\\\",
+		'"':  "\\\"",
+	}[b]
+	if ok {
+		return escaped, false
+	}
+
+	if 0x20 <= b && b <= 0x7e {
+		return fmt.Sprintf("%c", b), false
+	} else {
+		return fmt.Sprintf("\\x%02x", b), true
+	}
+}
+
+// writeCString writes in as a C string literal, keeping printable characters
+// and escaping the rest.
+func writeCString(out io.Writer, in io.Reader) error {
+	fmt.Fprint(out, "\"")
+	var err error
+	var n int
+	var lastOutWasHex bool
+	buf := make([]byte, opts.BufferSize)
+	for err == nil {
+		n, err = in.Read(buf)
+		for _, b := range buf[:n] {
+			str, isHex := makeCPrintSafe(b)
+			if lastOutWasHex && !isHex {
+				// split string literal to avoid problems like this
+				// { 0x00, 'a' } -> "\x00a" could be parsed wrong
+				fmt.Fprint(out, "\" \"")
+			}
+			fmt.Fprint(out, str)
+			lastOutWasHex = isHex
+		}
+	}
+	if err != io.EOF {
+		return err
+	}
+	fmt.Fprint(out, "\"")
+	return nil
+}
+
+// upperHexWriter upcases the hex digits a-f written through it. Everything
+// following a '|' up to the end of the line is left untouched, which keeps the
+// ASCII column of hex dumps intact.
+type upperHexWriter struct {
+	w        io.Writer
+	inGutter bool
+}
+
+func (u *upperHexWriter) Write(p []byte) (int, error) {
+	buf := make([]byte, len(p))
+	for i, b := range p {
+		switch {
+		case b == '\n':
+			u.inGutter = false
+		case b == '|':
+			u.inGutter = true
+		case !u.inGutter && 'a' <= b && b <= 'f':
+			b -= 'a' - 'A'
+		}
+		buf[i] = b
+	}
+	return u.w.Write(buf)
+}
+
+// hexOutput wraps out such that hex digits are upcased if requested.
+func hexOutput(out io.Writer) io.Writer {
+	if opts.Upper {
+		return &upperHexWriter{w: out}
+	}
+	return out
+}
+
+// newHash returns a new hash created by h, or an HMAC based on h if
+// opts.HMACKey is set.
+func newHash(h func() hash.Hash) hash.Hash {
+	if opts.HMACKey != nil {
+		return hmac.New(h, opts.HMACKey)
+	}
+	return h()
+}
+
+// writeDigest streams in through h and writes the resulting digest in hex,
+// truncated to opts.HashTruncate characters if that is set.
+func writeDigest(out io.Writer, in io.Reader, h hash.Hash) error {
+	_, err := io.Copy(h, in)
+	if err != nil {
+		return err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	if opts.HashTruncate > len(digest) {
+		return fmt.Errorf("cannot truncate the digest to %d characters, it only has %d", opts.HashTruncate, len(digest))
+	}
+	if opts.HashTruncate > 0 {
+		digest = digest[:opts.HashTruncate]
+	}
+	fmt.Fprint(hexOutput(out), digest)
+	return nil
+}
+
+func writeBase64(out io.Writer, in io.Reader, encoding *base64.Encoding) error {
+	if opts.NoPadding {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	enc := base64.NewEncoder(encoding, out)
+	_, err := io.Copy(enc, in)
+	if err != nil {
+		return err
+	}
+	// Close flushes the final partial block.
+	return enc.Close()
+}
+
+// makePythonPrintSafe escapes b for a Python bytes literal delimited by quote.
+func makePythonPrintSafe(b byte, quote byte) string {
+	if b == quote {
+		return "\\" + string(b)
+	}
+	escaped, ok := map[byte]string{
+		'\t': "\\t",
+		'\n': "\\n",
+		'\r': "\\r",
+		'\\': "\\\\",
+	}[b]
+	if ok {
+		return escaped
+	}
+
+	if 32 <= b && b <= 126 {
+		return fmt.Sprintf("%c", b)
+	}
+	return fmt.Sprintf("\\x%02x", b)
+}
+
+// isUnreserved reports whether b may appear unescaped in a URL according to
+// RFC 3986.
+func isUnreserved(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' ||
+		b == '-' || b == '.' || b == '_' || b == '~'
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// printableReader replaces all bytes that are not printable ASCII with '.'.
+type printableReader struct {
+	r io.Reader
+}
+
+func (p printableReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	for i, b := range buf[:n] {
+		if b < 32 || b > 126 {
+			buf[i] = '.'
+		}
+	}
+	return n, err
+}
+
+// columnLayout describes how the bytes of line based formats are laid out.
+type columnLayout struct {
+	// format is the format of a single byte.
+	format string
+	// sep is written between two bytes of the same line.
+	sep string
+	// indent is written at the start of every line.
+	indent string
+	// lineSep is written between two lines.
+	lineSep string
+	// end is written after the last line.
+	end string
+	// perLine is the maximum number of bytes per line.
+	perLine int
+}
+
+// write streams in and prints every byte according to the layout.
+func (l columnLayout) write(out io.Writer, in io.Reader) error {
+	var err error
+	var n int
+	var col int
+	var wroteAny bool
+	buf := make([]byte, opts.BufferSize)
+	for err == nil {
+		n, err = in.Read(buf)
+
+		for _, b := range buf[:n] {
+			if col == l.perLine {
+				fmt.Fprint(out, l.lineSep)
+				col = 0
+			} else if col > 0 {
+				fmt.Fprint(out, l.sep)
+			}
+			if col == 0 {
+				fmt.Fprint(out, l.indent)
+			}
+			fmt.Fprintf(out, l.format, b)
+			col++
+			wroteAny = true
+		}
+	}
+	if err != io.EOF {
+		return err
+	}
+	if wroteAny {
+		fmt.Fprint(out, l.end)
+	}
+	return nil
+}
+
+// writeColumns streams in and prints every byte using format, separating
+// bytes with sep and starting a new line after perLine bytes.
+func writeColumns(out io.Writer, in io.Reader, format, sep string, perLine int) error {
+	return columnLayout{
+		format:  format,
+		sep:     sep,
+		lineSep: "\n",
+		perLine: perLine,
+	}.write(out, in)
+}
+
+// formats is the registry of all output formats in the order they are listed.
+var formats = []FormatInfo{
+	{
+		Name:        "raw",
+		Description: "the bytes as they are",
+		binary:      true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			_, err := io.Copy(out, in)
+			return err
+		},
+	},
+	{
+		Name:        "hex",
+		Description: "hex encoded string",
+		formatter: func(out io.Writer, in io.Reader) error {
+			enc := hex.NewEncoder(hexOutput(out))
+			if opts.Group == 0 {
+				_, err := io.Copy(enc, in)
+				return err
+			}
+
+			var err error
+			var n int
+			// pos is kept across reads, as a group may span two of them.
+			var pos int64
+			buf := make([]byte, opts.BufferSize)
+			for err == nil {
+				n, err = in.Read(buf)
+
+				for _, b := range buf[:n] {
+					if pos > 0 && pos%int64(opts.Group) == 0 {
+						fmt.Fprint(out, opts.Sep)
+					}
+					enc.Write([]byte{b})
+					pos++
+				}
+			}
+			if err != io.EOF {
+				return err
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "dump",
+		Description: "hex dump with offsets and ASCII column",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeDump(hexOutput(out), in, opts.Offset, opts.lineWidth(16))
+		},
+	},
+	{
+		Name:        "gobytes",
+		Description: "Go []byte literal",
+		formatter: func(out io.Writer, in io.Reader) error {
+			data, err := ioutil.ReadAll(in)
+			if err != nil {
+				return err
+			}
+			if opts.VarName != "" {
+				fmt.Fprintf(out, "var %s = ", opts.VarName)
+			}
+			fmt.Fprintf(out, "%#v", data)
+			return nil
+		},
+	},
+	{
+		Name:        "gostring",
+		Description: "Go string literal",
+		formatter: func(out io.Writer, in io.Reader) error {
+			data, err := ioutil.ReadAll(in)
+			if err != nil {
+				return err
+			}
+			if opts.VarName != "" {
+				fmt.Fprintf(out, "var %s = ", opts.VarName)
+			}
+			fmt.Fprintf(out, "%#v", string(data))
+			return nil
+		},
+	},
+	{
+		Name:        "cstring",
+		Description: "C string literal keeping printable characters, escaping the rest",
+		formatter:   writeCString,
+	},
+	{
+		Name:        "cstring_hex",
+		Description: "C string literal with every byte hex escaped",
+		formatter: func(out io.Writer, in io.Reader) error {
+			fmt.Fprint(out, "\"")
+			var err error
+			var n int
+			buf := make([]byte, opts.BufferSize)
+			for err == nil {
+				n, err = in.Read(buf)
+				for _, b := range buf[:n] {
+					fmt.Fprintf(out, "\\x%02x", b)
+				}
+			}
+			if err != io.EOF {
+				return err
+			}
+			fmt.Fprint(out, "\"")
+			return nil
+		},
+	},
+	{
+		// cstring_unsafe is the former name of cstring.
+		Name:      "cstring_unsafe",
+		hidden:    true,
+		formatter: writeCString,
+	},
+	{
+		Name:        "base64",
+		Description: "standard base64 encoding",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeBase64(out, in, base64.StdEncoding)
+		},
+	},
+	{
+		Name:        "md5",
+		Description: "MD5 digest",
+		Digest:      true,
+		hmac:        true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeDigest(out, in, newHash(md5.New))
+		},
+	},
+	{
+		Name:        "sha256",
+		Description: "SHA-256 digest",
+		Digest:      true,
+		hmac:        true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeDigest(out, in, newHash(sha256.New))
+		},
+	},
+	{
+		Name:        "sha1",
+		Description: "SHA-1 digest",
+		Digest:      true,
+		hmac:        true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeDigest(out, in, newHash(sha1.New))
+		},
+	},
+	{
+		Name:        "sha512",
+		Description: "SHA-512 digest",
+		Digest:      true,
+		hmac:        true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeDigest(out, in, newHash(sha512.New))
+		},
+	},
+	{
+		Name:        "crc32",
+		Description: "CRC-32 checksum (IEEE)",
+		Digest:      true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeDigest(out, in, crc32.NewIEEE())
+		},
+	},
+	{
+		Name:        "crc64",
+		Description: "CRC-64 checksum (ECMA)",
+		Digest:      true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeDigest(out, in, crc64.New(crc64.MakeTable(crc64.ECMA)))
+		},
+	},
+	{
+		Name:        "adler32",
+		Description: "Adler-32 checksum",
+		Digest:      true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeDigest(out, in, adler32.New())
+		},
+	},
+	{
+		Name:        "blake2b",
+		Description: "BLAKE2b digest, see --hash-size",
+		Digest:      true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			enc, err := blake2b.New(opts.HashSize, nil)
+			if err != nil {
+				return err
+			}
+			return writeDigest(out, in, enc)
+		},
+	},
+	{
+		Name:        "base32",
+		Description: "standard base32 encoding",
+		formatter: func(out io.Writer, in io.Reader) error {
+			encoding := base32.StdEncoding
+			if opts.NoPadding {
+				encoding = encoding.WithPadding(base32.NoPadding)
+			}
+			enc := base32.NewEncoder(encoding, out)
+			_, err := io.Copy(enc, in)
+			if err != nil {
+				return err
+			}
+			return enc.Close()
+		},
+	},
+	{
+		Name:        "base64url",
+		Description: "URL safe base64 encoding",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeBase64(out, in, base64.URLEncoding)
+		},
+	},
+	{
+		Name:        "ascii85",
+		Description: "ascii85 encoding",
+		formatter: func(out io.Writer, in io.Reader) error {
+			enc := ascii85.NewEncoder(out)
+			_, err := io.Copy(enc, in)
+			if err != nil {
+				return err
+			}
+			// Close flushes the final partial group.
+			return enc.Close()
+		},
+	},
+	{
+		Name:        "binary",
+		Description: "bits of every byte",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeColumns(out, in, "%08b", " ", opts.lineWidth(8))
+		},
+	},
+	{
+		Name:        "octal",
+		Description: "octal value of every byte",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeColumns(out, in, "%03o", " ", opts.lineWidth(16))
+		},
+	},
+	{
+		Name:        "decimal",
+		Description: "decimal value of every byte",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeColumns(out, in, "%d", " ", opts.lineWidth(16))
+		},
+	},
+	{
+		Name:        "pystring",
+		Description: "Python bytes literal",
+		formatter: func(out io.Writer, in io.Reader) error {
+			// Like repr, double quotes are used if the data contains single
+			// quotes but no double quotes, so the input needs to be
+			// buffered.
+			data, err := ioutil.ReadAll(in)
+			if err != nil {
+				return err
+			}
+			quote := byte('\'')
+			if bytes.IndexByte(data, '\'') >= 0 && bytes.IndexByte(data, '"') < 0 {
+				quote = '"'
+			}
+			fmt.Fprintf(out, "b%c", quote)
+			for _, b := range data {
+				fmt.Fprint(out, makePythonPrintSafe(b, quote))
+			}
+			fmt.Fprintf(out, "%c", quote)
+			return nil
+		},
+	},
+	{
+		Name:        "rust",
+		Description: "Rust byte array literal",
+		formatter: func(out io.Writer, in io.Reader) error {
+			layout := columnLayout{
+				format:  "0x%02x",
+				sep:     ", ",
+				indent:  "    ",
+				lineSep: ",\n",
+				end:     ",\n",
+				perLine: opts.lineWidth(12),
+			}
+			if !opts.FixedSize {
+				fmt.Fprintf(out, "const %s: &[u8] = &[\n", opts.varName("DATA"))
+				err := layout.write(out, in)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(out, "];")
+				return nil
+			}
+			// The length is part of the type, so the input needs to be buffered.
+			data, err := ioutil.ReadAll(in)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "const %s: [u8; %d] = [\n", opts.varName("DATA"), len(data))
+			layout.write(out, bytes.NewReader(data))
+			fmt.Fprint(out, "];")
+			return nil
+		},
+	},
+	{
+		Name:        "java",
+		Description: "Java byte array literal",
+		formatter: func(out io.Writer, in io.Reader) error {
+			if opts.VarName != "" {
+				fmt.Fprintf(out, "byte[] %s = ", opts.VarName)
+			}
+			fmt.Fprint(out, "new byte[]{\n")
+			// Java bytes are signed, hence the cast for values above 0x7f.
+			err := columnLayout{
+				format:  "(byte)0x%02x",
+				sep:     ", ",
+				indent:  "    ",
+				lineSep: ",\n",
+				end:     ",\n",
+				perLine: opts.lineWidth(8),
+			}.write(out, in)
+			if err != nil {
+				return err
+			}
+			if opts.VarName != "" {
+				fmt.Fprint(out, "};")
+			} else {
+				fmt.Fprint(out, "}")
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "json",
+		Description: "JSON array of byte values",
+		formatter: func(out io.Writer, in io.Reader) error {
+			fmt.Fprint(out, "[")
+			var err error
+			var n int
+			var first = true
+			buf := make([]byte, opts.BufferSize)
+			for err == nil {
+				n, err = in.Read(buf)
+				for _, b := range buf[:n] {
+					if !first {
+						fmt.Fprint(out, ",")
+					}
+					fmt.Fprintf(out, "%d", b)
+					first = false
+				}
+			}
+			if err != io.EOF {
+				return err
+			}
+			fmt.Fprint(out, "]")
+			return nil
+		},
+	},
+	{
+		Name:        "carray",
+		Description: "C unsigned char array",
+		formatter: func(out io.Writer, in io.Reader) error {
+			counter := &countingReader{r: in}
+			name := opts.varName("data")
+			fmt.Fprintf(out, "unsigned char %s[] = {\n", name)
+			err := columnLayout{
+				format:  "0x%02x",
+				sep:     ", ",
+				indent:  "    ",
+				lineSep: ",\n",
+				end:     ",\n",
+				perLine: opts.lineWidth(12),
+			}.write(out, counter)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(out, "};")
+			if opts.WithLength {
+				fmt.Fprintf(out, "\nunsigned int %s_len = %d;", name, counter.n)
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "nasm",
+		Description: "NASM db directives",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return columnLayout{
+				format:  "0x%02x",
+				sep:     ", ",
+				indent:  "db ",
+				lineSep: "\n",
+				perLine: opts.lineWidth(16),
+			}.write(out, in)
+		},
+	},
+	{
+		Name:        "ihex",
+		Description: "Intel HEX records",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeIntelHex(out, in, opts.Offset, opts.lineWidth(16))
+		},
+	},
+	{
+		Name:        "srec",
+		Description: "Motorola S-records",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeSRec(out, in, opts.Offset, opts.lineWidth(16), opts.AddressBits)
+		},
+	},
+	{
+		Name:        "xxd",
+		Description: "hex dump in the layout of xxd",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeXxd(out, in, opts.Offset, opts.lineWidth(16))
+		},
+	},
+	{
+		Name:        "strings",
+		Description: "printable character runs like strings(1), see --min-len",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeStrings(out, in, opts.Offset, opts.MinLen, opts.WithOffset)
+		},
+	},
+	{
+		Name:        "entropy",
+		Description: "Shannon entropy in bits per byte, see --window",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeEntropy(out, in, opts.Offset, opts.Window)
+		},
+	},
+	{
+		Name:        "histogram",
+		Description: "frequency of every byte value, see --top",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeHistogram(out, in, opts.Top)
+		},
+	},
+	{
+		Name:        "utf16",
+		Description: "UTF-16 text decoded to UTF-8, see --endian",
+		verbatim: func() bool {
+			return true
+		},
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeUTF16(out, in, opts.BigEndian)
+		},
+	},
+	{
+		Name:        "ascii",
+		Description: "printable characters with others replaced by '.', see --width",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return columnLayout{
+				format:  "%c",
+				lineSep: "\n",
+				perLine: opts.lineWidth(64),
+			}.write(out, printableReader{in})
+		},
+	},
+	{
+		Name:        "powershell",
+		Description: "PowerShell byte array literal",
+		formatter: func(out io.Writer, in io.Reader) error {
+			if opts.VarName != "" {
+				fmt.Fprintf(out, "$%s = ", opts.VarName)
+			}
+			fmt.Fprint(out, "[byte[]]@(")
+			// Lines are continued with a backtick, which has to be the last
+			// character of the line.
+			err := columnLayout{
+				format:  "0x%02x",
+				sep:     ", ",
+				lineSep: ", `\n    ",
+				perLine: opts.lineWidth(12),
+			}.write(out, in)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(out, ")")
+			return nil
+		},
+	},
+	{
+		Name:        "swift",
+		Description: "Swift byte array literal",
+		formatter: func(out io.Writer, in io.Reader) error {
+			fmt.Fprintf(out, "let %s: [UInt8] = [\n", opts.varName("data"))
+			err := columnLayout{
+				format:  "0x%02x",
+				sep:     ", ",
+				indent:  "    ",
+				lineSep: ",\n",
+				end:     ",\n",
+				perLine: opts.lineWidth(12),
+			}.write(out, in)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(out, "]")
+			return nil
+		},
+	},
+	{
+		Name:        "base58",
+		Description: "base58 encoding with the Bitcoin alphabet, buffers the whole input",
+		formatter:   writeBase58,
+	},
+	{
+		Name:        "z85",
+		Description: "ZeroMQ Z85 encoding, the input length must be a multiple of 4",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeZ85(out, in, opts.ZeroPad)
+		},
+	},
+	{
+		Name:        "quotedprintable",
+		Description: "quoted-printable encoding as used for binary MIME bodies",
+		formatter: func(out io.Writer, in io.Reader) error {
+			enc := quotedprintable.NewWriter(out)
+			// Line breaks of the input are data, not text structure.
+			enc.Binary = true
+			_, err := io.Copy(enc, in)
+			if err != nil {
+				return err
+			}
+			// Close flushes the buffered last line.
+			return enc.Close()
+		},
+	},
+	{
+		Name:        "urlencode",
+		Description: "URL percent-encoding of every byte",
+		formatter: func(out io.Writer, in io.Reader) error {
+			escape := "%%%02x"
+			if opts.Upper {
+				escape = "%%%02X"
+			}
+			var err error
+			var n int
+			buf := make([]byte, opts.BufferSize)
+			for err == nil {
+				n, err = in.Read(buf)
+				for _, b := range buf[:n] {
+					if opts.KeepUnreserved && isUnreserved(b) {
+						out.Write([]byte{b})
+					} else {
+						fmt.Fprintf(out, escape, b)
+					}
+				}
+			}
+			if err != io.EOF {
+				return err
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "gzip",
+		Description: "gzip compressed data",
+		binary:      true,
+		formatter: func(out io.Writer, in io.Reader) error {
+			zw := gzip.NewWriter(out)
+			_, err := io.Copy(zw, in)
+			if err != nil {
+				return err
+			}
+			// Close flushes the compressed data and writes the trailer.
+			return zw.Close()
+		},
+	},
+	{
+		Name:        "jsonl",
+		Description: "JSON lines holding the offset and the hex encoded bytes of every row",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeJSONLines(out, in, opts.Offset, opts.lineWidth(16))
+		},
+	},
+	{
+		Name:        "yaml",
+		Description: "YAML sequence of byte values, see --yaml-binary",
+		formatter: func(out io.Writer, in io.Reader) error {
+			if opts.YAMLBinary {
+				return writeYAMLBinary(out, in)
+			}
+			return writeYAMLSequence(out, in)
+		},
+	},
+	{
+		Name:        "csv",
+		Description: "comma separated byte values, see --csv-hex",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeCSV(out, in, opts.lineWidth(16), opts.CSVHex)
+		},
+	},
+	{
+		Name:        "filetype",
+		Description: "guessed type of the data based on its first bytes",
+		formatter:   writeFileType,
+	},
+	{
+		Name:        "struct",
+		Description: "integer and float fields described by --spec as name=value lines",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeStruct(out, in, opts.Spec)
+		},
+	},
+	{
+		Name:        "offsets",
+		Description: "one line per byte with its offset and value, e.g. 0x0000: 0xde",
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeOffsets(out, in, opts.Offset, opts.OffsetDigits, opts.Char)
+		},
+	},
+	{
+		Name:        "utf8check",
+		Description: "whether the input is valid UTF-8, repaired text with --repair",
+		verbatim: func() bool {
+			return opts.Repair
+		},
+		formatter: func(out io.Writer, in io.Reader) error {
+			return writeUTF8Check(out, in, opts.Offset, opts.Repair)
+		},
+	},
+}
@@ -0,0 +1,50 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// writeHistogram writes how often every byte value occurs in in, most frequent
+// first. Only the top most frequent values are written if top is positive.
+func writeHistogram(out io.Writer, in io.Reader, top int) error {
+	var counts [256]uint64
+	var total uint64
+
+	var err error
+	var n int
+	buf := make([]byte, opts.BufferSize)
+	for err == nil {
+		n, err = in.Read(buf)
+
+		for _, b := range buf[:n] {
+			counts[b]++
+		}
+		total += uint64(n)
+	}
+	if err != io.EOF {
+		return err
+	}
+
+	var values []int
+	for value, count := range counts {
+		if count > 0 {
+			values = append(values, value)
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return counts[values[i]] > counts[values[j]]
+	})
+	if top > 0 && top < len(values) {
+		values = values[:top]
+	}
+
+	lines := &lineWriter{out: out}
+	countWidth := len(fmt.Sprint(total))
+	for _, value := range values {
+		percentage := 100 * float64(counts[value]) / float64(total)
+		lines.printf("0x%02x  %*d  %6.2f%%", value, countWidth, counts[value], percentage)
+	}
+	return nil
+}
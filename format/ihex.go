@@ -0,0 +1,71 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	ihexData                  = 0x00
+	ihexEndOfFile             = 0x01
+	ihexExtendedLinearAddress = 0x04
+
+	ihexMaxRecordSize = 0xff
+)
+
+// intelHexRecord returns a single Intel HEX record including its checksum.
+func intelHexRecord(recordType byte, address uint16, data []byte) string {
+	var record strings.Builder
+	sum := byte(len(data)) + byte(address>>8) + byte(address) + recordType
+	fmt.Fprintf(&record, ":%02X%04X%02X", len(data), address, recordType)
+	for _, b := range data {
+		fmt.Fprintf(&record, "%02X", b)
+		sum += b
+	}
+	fmt.Fprintf(&record, "%02X", -sum)
+	return record.String()
+}
+
+// writeIntelHex writes in as Intel HEX data records of at most recordSize
+// bytes, starting at the load address address. Extended linear address
+// records are emitted whenever the upper 16 bits of the address change.
+func writeIntelHex(out io.Writer, in io.Reader, address int64, recordSize int) error {
+	if recordSize > ihexMaxRecordSize {
+		return fmt.Errorf("intel hex records can hold at most %d bytes, got %d", ihexMaxRecordSize, recordSize)
+	}
+
+	lines := &lineWriter{out: out}
+	var upper int64
+	buf := make([]byte, recordSize)
+	for {
+		// Records must not cross a 64KiB boundary, as the 16 bit address
+		// would wrap around.
+		n := recordSize
+		if remaining := 0x10000 - int(address&0xffff); remaining < n {
+			n = remaining
+		}
+
+		n, err := io.ReadFull(in, buf[:n])
+		if n > 0 {
+			if address+int64(n)-1 > 0xffffffff {
+				return fmt.Errorf("address 0x%X exceeds the 32 bit address space of intel hex", address+int64(n)-1)
+			}
+			if address>>16 != upper {
+				upper = address >> 16
+				lines.line(intelHexRecord(ihexExtendedLinearAddress, 0, []byte{byte(upper >> 8), byte(upper)}))
+			}
+			lines.line(intelHexRecord(ihexData, uint16(address), buf[:n]))
+			address += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	lines.line(intelHexRecord(ihexEndOfFile, 0, nil))
+	return nil
+}
@@ -0,0 +1,148 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// sequence returns n bytes counting up from 0.
+func sequence(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+func TestIntelHexRecord(t *testing.T) {
+	tests := []struct {
+		name       string
+		recordType byte
+		address    uint16
+		data       []byte
+		want       string
+	}{
+		{
+			name:       "data",
+			recordType: ihexData,
+			address:    0x0100,
+			data:       []byte{0x21, 0x46, 0x01, 0x36, 0x01, 0x21, 0x47, 0x01, 0x36, 0x00, 0x7e, 0xfe, 0x09, 0xd2, 0x19, 0x01},
+			want:       ":10010000214601360121470136007EFE09D2190140",
+		},
+		{
+			name:       "extended linear address",
+			recordType: ihexExtendedLinearAddress,
+			data:       []byte{0x00, 0x01},
+			want:       ":020000040001F9",
+		},
+		{
+			name:       "end of file",
+			recordType: ihexEndOfFile,
+			want:       ":00000001FF",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := intelHexRecord(test.recordType, test.address, test.data)
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteIntelHex(t *testing.T) {
+	tests := []struct {
+		name       string
+		address    int64
+		data       []byte
+		recordSize int
+		want       []string
+	}{
+		{
+			name:       "empty",
+			recordSize: 16,
+			want:       []string{":00000001FF"},
+		},
+		{
+			name:       "short last record",
+			data:       sequence(20),
+			recordSize: 16,
+			want: []string{
+				":10000000000102030405060708090A0B0C0D0E0F78",
+				":0400100010111213A6",
+				":00000001FF",
+			},
+		},
+		{
+			name:       "split at 64KiB boundary",
+			address:    0xfff0,
+			data:       sequence(40),
+			recordSize: 16,
+			want: []string{
+				":10FFF000000102030405060708090A0B0C0D0E0F89",
+				":020000040001F9",
+				":10000000101112131415161718191A1B1C1D1E1F78",
+				":080010002021222324252627CC",
+				":00000001FF",
+			},
+		},
+		{
+			name:       "record crossing 64KiB boundary",
+			address:    0xfffc,
+			data:       sequence(8),
+			recordSize: 16,
+			want: []string{
+				":04FFFC0000010203FB",
+				":020000040001F9",
+				":0400000004050607E6",
+				":00000001FF",
+			},
+		},
+		{
+			name:       "extended linear address up front",
+			address:    0x12340000,
+			data:       []byte{0xab},
+			recordSize: 16,
+			want: []string{
+				":020000041234B4",
+				":01000000AB54",
+				":00000001FF",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := writeIntelHex(&out, bytes.NewReader(test.data), test.address, test.recordSize)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := strings.Join(test.want, "\n")
+			if out.String() != want {
+				t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestWriteIntelHexErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		address    int64
+		data       []byte
+		recordSize int
+	}{
+		{"record size too large", 0, sequence(1), 256},
+		{"beyond 32 bit address space", 0xfffffffe, sequence(4), 16},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := writeIntelHex(&bytes.Buffer{}, bytes.NewReader(test.data), test.address, test.recordSize)
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package format
+
+import (
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// writeJSONLines writes one JSON object per row of cols bytes, holding the
+// offset of the row and its bytes in hex. The first row is labelled with
+// offset.
+func writeJSONLines(out io.Writer, in io.Reader, offset int64, cols int) error {
+	lines := &lineWriter{out: out}
+	row := make([]byte, cols)
+	for {
+		n, err := io.ReadFull(in, row)
+		if n > 0 {
+			encoded := hex.EncodeToString(row[:n])
+			if opts.Upper {
+				encoded = strings.ToUpper(encoded)
+			}
+			lines.printf("{\"offset\":%d,\"bytes\":\"%s\"}", offset, encoded)
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package format
+
+import (
+	"fmt"
+	"io"
+)
+
+// lineWriter writes lines to out separated by newlines. The newline after the
+// last line is left to Format.
+type lineWriter struct {
+	out     io.Writer
+	started bool
+}
+
+// line writes s as the next line.
+func (l *lineWriter) line(s string) {
+	if l.started {
+		io.WriteString(l.out, "\n")
+	}
+	l.started = true
+	io.WriteString(l.out, s)
+}
+
+// printf writes the next line formatted according to format.
+func (l *lineWriter) printf(format string, args ...interface{}) {
+	l.line(fmt.Sprintf(format, args...))
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
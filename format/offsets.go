@@ -0,0 +1,38 @@
+package format
+
+import (
+	"bufio"
+	"io"
+)
+
+// writeOffsets writes every byte of in on its own line as its offset and
+// value in hex, followed by its ASCII character if withChar is set. offset is
+// the position of the first byte of in and is padded to digits hex digits.
+func writeOffsets(out io.Writer, in io.Reader, offset int64, digits int, withChar bool) error {
+	lineFormat := "0x%0*x: 0x%02x"
+	if opts.Upper {
+		lineFormat = "0x%0*X: 0x%02X"
+	}
+
+	var err error
+	var n int
+	pos := offset
+	w := bufio.NewWriter(out)
+	lines := &lineWriter{out: w}
+	buf := make([]byte, opts.BufferSize)
+	for err == nil {
+		n, err = in.Read(buf)
+		for _, b := range buf[:n] {
+			if withChar {
+				lines.printf(lineFormat+" %s", digits, pos, b, printableASCII([]byte{b}))
+			} else {
+				lines.printf(lineFormat, digits, pos, b)
+			}
+			pos++
+		}
+	}
+	if err != io.EOF {
+		return err
+	}
+	return w.Flush()
+}
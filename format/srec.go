@@ -0,0 +1,95 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// srecHeader is the payload of the S0 header record.
+const srecHeader = "slice"
+
+// srecRecordTypes maps the supported address widths in bytes to the record
+// types used for data and termination records.
+var srecRecordTypes = map[int]struct{ data, termination byte }{
+	2: {'1', '9'},
+	3: {'2', '8'},
+	4: {'3', '7'},
+}
+
+// srecChecksum computes the checksum of a record as the ones' complement of
+// the least significant byte of the sum of count, address and data bytes.
+func srecChecksum(count byte, address []byte, data []byte) byte {
+	sum := count
+	for _, b := range address {
+		sum += b
+	}
+	for _, b := range data {
+		sum += b
+	}
+	return ^sum
+}
+
+// sRecord returns a single Motorola S-record with an address of addressWidth
+// bytes.
+func sRecord(recordType byte, addressWidth int, address uint32, data []byte) string {
+	addr := make([]byte, addressWidth)
+	for i := range addr {
+		addr[i] = byte(address >> (8 * uint(addressWidth-1-i)))
+	}
+	count := byte(addressWidth + len(data) + 1)
+
+	var record strings.Builder
+	fmt.Fprintf(&record, "S%c%02X", recordType, count)
+	for _, b := range addr {
+		fmt.Fprintf(&record, "%02X", b)
+	}
+	for _, b := range data {
+		fmt.Fprintf(&record, "%02X", b)
+	}
+	fmt.Fprintf(&record, "%02X", srecChecksum(count, addr, data))
+	return record.String()
+}
+
+// writeSRec writes in as Motorola S-records of at most recordSize data bytes
+// each, starting at address. addressBits selects between S1/S9 (16), S2/S8 (24)
+// and S3/S7 (32) records.
+func writeSRec(out io.Writer, in io.Reader, address int64, recordSize int, addressBits int) error {
+	addressWidth := addressBits / 8
+	types, ok := srecRecordTypes[addressWidth]
+	if !ok || addressBits%8 != 0 {
+		return fmt.Errorf("unsupported s-record address width %d, expected 16, 24 or 32", addressBits)
+	}
+	if max := 0xff - addressWidth - 1; recordSize > max {
+		return fmt.Errorf("s-records with %d bit addresses can hold at most %d bytes, got %d", addressBits, max, recordSize)
+	}
+	maxAddress := int64(1)<<uint(addressBits) - 1
+	if address > maxAddress {
+		return fmt.Errorf("address 0x%X exceeds the %d bit address space", address, addressBits)
+	}
+	start := uint32(address)
+
+	lines := &lineWriter{out: out}
+	lines.line(sRecord('0', 2, 0, []byte(srecHeader)))
+
+	buf := make([]byte, recordSize)
+	for {
+		n, err := io.ReadFull(in, buf)
+		if n > 0 {
+			if address+int64(n)-1 > maxAddress {
+				return fmt.Errorf("address 0x%X exceeds the %d bit address space", address+int64(n)-1, addressBits)
+			}
+			lines.line(sRecord(types.data, addressWidth, uint32(address), buf[:n]))
+			address += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	lines.line(sRecord(types.termination, addressWidth, start, nil))
+	return nil
+}
@@ -0,0 +1,142 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSRecChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   byte
+		address []byte
+		data    []byte
+		want    byte
+	}{
+		{
+			name:    "reference data record",
+			count:   0x1f,
+			address: []byte{0x00, 0x00},
+			data: []byte{
+				0x7c, 0x08, 0x02, 0xa6, 0x90, 0x01, 0x00, 0x04, 0x94, 0x21, 0xff, 0xf0, 0x7c, 0x6c,
+				0x1b, 0x78, 0x7c, 0x8c, 0x23, 0x78, 0x3c, 0x60, 0x00, 0x00, 0x38, 0x63, 0x00, 0x00,
+			},
+			want: 0x26,
+		},
+		{
+			name:    "reference termination record",
+			count:   0x03,
+			address: []byte{0x00, 0x00},
+			want:    0xfc,
+		},
+		{
+			name:    "sum overflowing a byte",
+			count:   0x04,
+			address: []byte{0xff, 0xff},
+			data:    []byte{0xff},
+			want:    0xfe,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := srecChecksum(test.count, test.address, test.data)
+			if got != test.want {
+				t.Errorf("got %02X, want %02X", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteSRec(t *testing.T) {
+	tests := []struct {
+		name        string
+		addressBits int
+		address     int64
+		want        []string
+	}{
+		{
+			name:        "16 bit",
+			addressBits: 16,
+			address:     0x1234,
+			want: []string{
+				"S0080000736C696365E7",
+				"S1061234000102B0",
+				"S9031234B6",
+			},
+		},
+		{
+			name:        "24 bit",
+			addressBits: 24,
+			address:     0x123456,
+			want: []string{
+				"S0080000736C696365E7",
+				"S20712345600010259",
+				"S8041234565F",
+			},
+		},
+		{
+			name:        "32 bit",
+			addressBits: 32,
+			address:     0x12345678,
+			want: []string{
+				"S0080000736C696365E7",
+				"S30812345678000102E0",
+				"S70512345678E6",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := writeSRec(&out, bytes.NewReader([]byte{0x00, 0x01, 0x02}), test.address, 16, test.addressBits)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := strings.Join(test.want, "\n")
+			if out.String() != want {
+				t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestWriteSRecSplitsRecords(t *testing.T) {
+	var out bytes.Buffer
+	err := writeSRec(&out, bytes.NewReader(sequence(5)), 0, 2, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := strings.Join([]string{
+		"S0080000736C696365E7",
+		"S10500000001F9",
+		"S10500020203F3",
+		"S104000404F3",
+		"S9030000FC",
+	}, "\n")
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestWriteSRecErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		address     int64
+		recordSize  int
+		addressBits int
+	}{
+		{"unsupported address width", 0, 16, 20},
+		{"record size too large", 0, 253, 16},
+		{"start beyond address space", 0x10000, 16, 16},
+		{"data beyond address space", 0xfffe, 16, 16},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := writeSRec(&bytes.Buffer{}, bytes.NewReader(sequence(4)), test.address, test.recordSize, test.addressBits)
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package format
+
+import (
+	"io"
+)
+
+// isStringByte reports whether b may be part of a string extracted by the
+// strings format.
+func isStringByte(b byte) bool {
+	return b == '\t' || (32 <= b && b <= 126)
+}
+
+// writeStrings writes every maximal run of at least minLen printable bytes on
+// its own line, optionally prefixed by its offset. offset is the position of
+// the first byte of in.
+func writeStrings(out io.Writer, in io.Reader, offset int64, minLen int, withOffset bool) error {
+	lines := &lineWriter{out: out}
+	var run []byte
+	var runStart int64
+	flush := func() {
+		if len(run) >= minLen {
+			if withOffset {
+				lines.printf(opts.offsetFormat()+" %s", runStart, run)
+			} else {
+				lines.line(string(run))
+			}
+		}
+		run = run[:0]
+	}
+
+	var err error
+	var n int
+	pos := offset
+	buf := make([]byte, opts.BufferSize)
+	for err == nil {
+		n, err = in.Read(buf)
+
+		// run is kept across reads, as a string may span two of them.
+		for _, b := range buf[:n] {
+			if isStringByte(b) {
+				if len(run) == 0 {
+					runStart = pos
+				}
+				run = append(run, b)
+			} else {
+				flush()
+			}
+			pos++
+		}
+	}
+	if err != io.EOF {
+		return err
+	}
+	flush()
+	return nil
+}
@@ -0,0 +1,110 @@
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// structField is a field of a struct spec.
+type structField struct {
+	name   string
+	size   int
+	signed bool
+	float  bool
+	order  binary.ByteOrder
+}
+
+// parseStructSpec parses a comma separated list of TYPE:NAME fields. TYPE is
+// one of u8, i8 or u16, i16, u32, i32, u64, i64, f32, f64 followed by le or
+// be.
+func parseStructSpec(spec string) ([]structField, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("the struct format requires a --spec")
+	}
+	var fields []structField
+	for _, item := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("field \"%s\" of spec is not TYPE:NAME", item)
+		}
+		typ := parts[0]
+		field := structField{name: parts[1], order: binary.LittleEndian}
+
+		switch {
+		case strings.HasSuffix(typ, "le"):
+			typ = strings.TrimSuffix(typ, "le")
+		case strings.HasSuffix(typ, "be"):
+			typ = strings.TrimSuffix(typ, "be")
+			field.order = binary.BigEndian
+		case typ != "u8" && typ != "i8":
+			return nil, fmt.Errorf("type \"%s\" of field \"%s\" lacks the byte order le or be", parts[0], field.name)
+		}
+
+		switch typ {
+		case "u8", "u16", "u32", "u64":
+		case "i8", "i16", "i32", "i64":
+			field.signed = true
+		case "f32", "f64":
+			field.float = true
+		default:
+			return nil, fmt.Errorf("unsupported type \"%s\" of field \"%s\"", parts[0], field.name)
+		}
+		fmt.Sscanf(typ[1:], "%d", &field.size)
+		field.size /= 8
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// format returns the value of the field stored in data.
+func (f structField) format(data []byte) string {
+	var value uint64
+	switch f.size {
+	case 1:
+		value = uint64(data[0])
+	case 2:
+		value = uint64(f.order.Uint16(data))
+	case 4:
+		value = uint64(f.order.Uint32(data))
+	case 8:
+		value = f.order.Uint64(data)
+	}
+
+	switch {
+	case f.float && f.size == 4:
+		return fmt.Sprint(math.Float32frombits(uint32(value)))
+	case f.float:
+		return fmt.Sprint(math.Float64frombits(value))
+	case f.signed:
+		// Sign extend the value to 64 bits.
+		shift := uint(64 - 8*f.size)
+		return fmt.Sprint(int64(value<<shift) >> shift)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// writeStruct reads the fields described by spec one after another from in
+// and writes them as name=value lines.
+func writeStruct(out io.Writer, in io.Reader, spec string) error {
+	fields, err := parseStructSpec(spec)
+	if err != nil {
+		return err
+	}
+	lines := &lineWriter{out: out}
+	buf := make([]byte, 8)
+	for _, field := range fields {
+		_, err := io.ReadFull(in, buf[:field.size])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("the input ends before field \"%s\"", field.name)
+		}
+		if err != nil {
+			return err
+		}
+		lines.printf("%s=%s", field.name, field.format(buf[:field.size]))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package format
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+// TemplateByte is the data a per byte template is executed with.
+type TemplateByte struct {
+	// Offset is the position of the byte within its file.
+	Offset int64
+	Byte   byte
+	// Hex is the byte as two hex digits.
+	Hex string
+	// Char is the byte if it is printable ASCII and "." otherwise.
+	Char string
+}
+
+// TemplateRow is the data a per row template is executed with.
+type TemplateRow struct {
+	// Offset is the position of the first byte of the row within its file.
+	Offset int64
+	Bytes  []byte
+	// Hex is the row in hex.
+	Hex string
+	// ASCII is the row with bytes that are not printable ASCII replaced by
+	// ".".
+	ASCII string
+}
+
+// printableASCII replaces all bytes of data that are not printable ASCII
+// with '.'.
+func printableASCII(data []byte) string {
+	printable := make([]byte, len(data))
+	for i, b := range data {
+		if 0x20 <= b && b <= 0x7e {
+			printable[i] = b
+		} else {
+			printable[i] = '.'
+		}
+	}
+	return string(printable)
+}
+
+// TemplateFormatter returns a formatter executing the text/template text for
+// every byte of its input with a TemplateByte, or for every row of rowBytes
+// bytes with a TemplateRow if rowBytes is positive. Offsets start at
+// Options.Offset.
+func TemplateFormatter(text string, rowBytes int) (Formatter, error) {
+	tmpl, err := template.New("template").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template, reason: %w", err)
+	}
+
+	if rowBytes > 0 {
+		return func(out io.Writer, in io.Reader) error {
+			offset := opts.Offset
+			row := make([]byte, rowBytes)
+			for {
+				n, err := io.ReadFull(in, row)
+				if n > 0 {
+					execErr := tmpl.Execute(out, TemplateRow{
+						Offset: offset,
+						Bytes:  row[:n],
+						Hex:    hex.EncodeToString(row[:n]),
+						ASCII:  printableASCII(row[:n]),
+					})
+					if execErr != nil {
+						return execErr
+					}
+					offset += int64(n)
+				}
+				if err == io.EOF || err == io.ErrUnexpectedEOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}, nil
+	}
+
+	return func(out io.Writer, in io.Reader) error {
+		offset := opts.Offset
+		var err error
+		var n int
+		buf := make([]byte, opts.BufferSize)
+		for err == nil {
+			n, err = in.Read(buf)
+			for _, b := range buf[:n] {
+				execErr := tmpl.Execute(out, TemplateByte{
+					Offset: offset,
+					Byte:   b,
+					Hex:    hex.EncodeToString([]byte{b}),
+					Char:   printableASCII([]byte{b}),
+				})
+				if execErr != nil {
+					return execErr
+				}
+				offset++
+			}
+		}
+		if err != io.EOF {
+			return err
+		}
+		return nil
+	}, nil
+}
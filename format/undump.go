@@ -0,0 +1,94 @@
+package format
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseDumpLine parses a line of a hex dump in the layout of hexdump -C, the
+// dump format, or xxd. It returns the offset and the bytes of the line, which
+// are nil for lines holding only an offset.
+func parseDumpLine(line string) (int64, []byte, error) {
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+	offsetField := strings.TrimSuffix(fields[0], ":")
+	offset, err := strconv.ParseInt(offsetField, 16, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid offset \"%s\"", fields[0])
+	}
+	if len(fields) == 1 {
+		return offset, nil, nil
+	}
+
+	hexPart := fields[1]
+	if strings.HasSuffix(fields[0], ":") {
+		// xxd separates the ASCII column by two spaces, whereas groups of
+		// bytes are separated by one.
+		hexPart = strings.TrimLeft(hexPart, " ")
+		if end := strings.Index(hexPart, "  "); end >= 0 {
+			hexPart = hexPart[:end]
+		}
+	} else if end := strings.Index(hexPart, "|"); end >= 0 {
+		hexPart = hexPart[:end]
+	}
+
+	data, err := hex.DecodeString(strings.Join(strings.Fields(hexPart), ""))
+	if err != nil {
+		return 0, nil, err
+	}
+	return offset, data, nil
+}
+
+// decodeHexDump reads a hex dump in the layout of hexdump -C, the dump format
+// or xxd and writes the dumped bytes. Lines squeezed by hexdump into a "*" are
+// restored using the offset of the following line.
+func decodeHexDump(out io.Writer, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	var lineNumber int
+	var pos int64
+	var started bool
+	var prev []byte
+	var squeezed bool
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.TrimSpace(line) == "*" {
+			if len(prev) == 0 {
+				return fmt.Errorf("line %d: \"*\" does not follow a line of bytes", lineNumber)
+			}
+			squeezed = true
+			continue
+		}
+
+		offset, data, err := parseDumpLine(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if !started {
+			pos = offset
+			started = true
+		}
+		if squeezed {
+			for pos+int64(len(prev)) <= offset {
+				out.Write(prev)
+				pos += int64(len(prev))
+			}
+			if pos != offset {
+				return fmt.Errorf("line %d: offset 0x%x does not continue the squeezed lines", lineNumber, offset)
+			}
+			squeezed = false
+		}
+		out.Write(data)
+		pos += int64(len(data))
+		if len(data) > 0 {
+			prev = data
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,94 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecodeHexDump(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+		want string
+	}{
+		{
+			name: "xxd",
+			dump: "00000000: 6865 6c6c 6f2c 2077 6f72 6c64 2100 01ff  hello, world!...\n" +
+				"00000010: 7f61 6263                                .abc\n",
+			want: "hello, world!\x00\x01\xff\x7fabc",
+		},
+		{
+			name: "xxd ascii column with double spaces",
+			dump: "00000000: 6120 2062                                a  b\n",
+			want: "a  b",
+		},
+		{
+			name: "hexdump -C with squeezed lines",
+			dump: "00000000  41 41 41 41 41 41 41 41  41 41 41 41 41 41 41 41  |AAAAAAAAAAAAAAAA|\n" +
+				"*\n" +
+				"00000030  62 63                                             |bc|\n" +
+				"00000032\n",
+			want: strings.Repeat("A", 48) + "bc",
+		},
+		{
+			name: "dump",
+			dump: "00000000  68 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 00 01 ff  |hello, world!...|\n" +
+				"00000010  7f 61 62 63                                       |.abc|\n",
+			want: "hello, world!\x00\x01\xff\x7fabc",
+		},
+		{
+			name: "dump with offset",
+			dump: "00000100  61 62                                             |ab|\n",
+			want: "ab",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := decodeHexDump(&out, strings.NewReader(test.dump))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != test.want {
+				t.Errorf("got %q, want %q", out.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeHexDumpRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("\x00", 40) + "slice\xfe")
+	var dump bytes.Buffer
+	err := writeDump(&dump, bytes.NewReader(data), 0, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out bytes.Buffer
+	err = decodeHexDump(&out, &dump)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("got %q, want %q", out.Bytes(), data)
+	}
+}
+
+func TestDecodeHexDumpInvalidSqueeze(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+	}{
+		{"leading squeeze", "*\n00000010  41\n"},
+		{"squeeze after line without bytes", "00000000  |abc|\n*\n00000010  41\n"},
+		{"offset not continuing squeeze", "00000000  41 42 43\n*\n00000004  41\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := decodeHexDump(&bytes.Buffer{}, strings.NewReader(test.dump))
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package format
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// byteOrder returns the big or little endian byte order.
+func byteOrder(bigEndian bool) binary.ByteOrder {
+	if bigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// writeUTF16 decodes in from UTF-16 and writes it as UTF-8. A leading byte
+// order mark is dropped and takes precedence over bigEndian. Unpaired
+// surrogates and a trailing odd byte are replaced with U+FFFD.
+func writeUTF16(out io.Writer, in io.Reader, bigEndian bool) error {
+	r := bufio.NewReader(in)
+	w := bufio.NewWriter(out)
+
+	// pending is a high surrogate waiting for its low surrogate, or -1.
+	var pending rune = -1
+	unit := make([]byte, 2)
+	for first := true; ; first = false {
+		_, err := io.ReadFull(r, unit)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			if pending >= 0 {
+				w.WriteRune(utf8.RuneError)
+			}
+			if err == io.ErrUnexpectedEOF {
+				// An odd number of bytes leaves half a code unit.
+				w.WriteRune(utf8.RuneError)
+			}
+			return w.Flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		c := rune(byteOrder(bigEndian).Uint16(unit))
+		if first && (c == 0xfeff || c == 0xfffe) {
+			if c == 0xfffe {
+				bigEndian = !bigEndian
+			}
+			continue
+		}
+
+		if pending >= 0 {
+			decoded := utf16.DecodeRune(pending, c)
+			pending = -1
+			if decoded != utf8.RuneError {
+				w.WriteRune(decoded)
+				continue
+			}
+			w.WriteRune(utf8.RuneError)
+		}
+		if 0xd800 <= c && c < 0xdc00 {
+			pending = c
+			continue
+		}
+		// WriteRune replaces a lone low surrogate with U+FFFD.
+		w.WriteRune(c)
+	}
+}
@@ -0,0 +1,70 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// writeUTF8Check reports whether in is valid UTF-8 and the offset of its
+// first invalid byte otherwise. offset is the position of the first byte of
+// in. If repair is set, in is written instead with every run of invalid bytes
+// replaced by U+FFFD, like strings.ToValidUTF8 does.
+func writeUTF8Check(out io.Writer, in io.Reader, offset int64, repair bool) error {
+	w := bufio.NewWriter(out)
+	firstInvalid := int64(-1)
+	// replaced is set if the last byte was invalid and has been replaced, so
+	// that a run of invalid bytes results in a single U+FFFD.
+	replaced := false
+	invalid := func(pos int64) {
+		if firstInvalid == -1 {
+			firstInvalid = pos
+		}
+		if repair && !replaced {
+			w.WriteRune(utf8.RuneError)
+		}
+		replaced = true
+	}
+
+	var err error
+	var n int
+	pos := offset
+	// pending holds the start of a rune that continues in the next read.
+	var pending []byte
+	buf := make([]byte, opts.BufferSize)
+	for err == nil {
+		n, err = in.Read(buf)
+		data := append(pending, buf[:n]...)
+		pending = nil
+		for len(data) > 0 {
+			if err == nil && !utf8.FullRune(data) {
+				pending = append(pending, data...)
+				break
+			}
+			r, size := utf8.DecodeRune(data)
+			if r == utf8.RuneError && size == 1 {
+				invalid(pos)
+			} else {
+				if repair {
+					w.Write(data[:size])
+				}
+				replaced = false
+			}
+			data = data[size:]
+			pos += int64(size)
+		}
+	}
+	if err != io.EOF {
+		return err
+	}
+
+	if !repair {
+		if firstInvalid == -1 {
+			fmt.Fprint(w, "valid UTF-8")
+		} else {
+			fmt.Fprintf(w, "invalid UTF-8, first invalid byte at offset 0x%X", firstInvalid)
+		}
+	}
+	return w.Flush()
+}
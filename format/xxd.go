@@ -0,0 +1,59 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xxdGroupSize is the number of bytes xxd groups together by default.
+const xxdGroupSize = 2
+
+// xxdLine returns a single line in the default layout of xxd.
+func xxdLine(offset int64, row []byte, cols int) string {
+	var line strings.Builder
+	fmt.Fprintf(&line, "%08x: ", offset)
+
+	hexWidth := 2*cols + (cols+xxdGroupSize-1)/xxdGroupSize - 1
+	var hexPart strings.Builder
+	for i, b := range row {
+		if i > 0 && i%xxdGroupSize == 0 {
+			hexPart.WriteByte(' ')
+		}
+		fmt.Fprintf(&hexPart, "%02x", b)
+	}
+	if opts.NoASCII {
+		line.WriteString(hexPart.String())
+		return line.String()
+	}
+	fmt.Fprintf(&line, "%-*s  ", hexWidth, hexPart.String())
+
+	for _, b := range row {
+		if 0x20 <= b && b <= 0x7e {
+			line.WriteByte(b)
+		} else {
+			line.WriteByte('.')
+		}
+	}
+	return line.String()
+}
+
+// writeXxd writes in the way xxd does by default with cols bytes per line,
+// labelling the first line with offset.
+func writeXxd(out io.Writer, in io.Reader, offset int64, cols int) error {
+	lines := &lineWriter{out: out}
+	row := make([]byte, cols)
+	for {
+		n, err := io.ReadFull(in, row)
+		if n > 0 {
+			lines.line(xxdLine(offset, row[:n], cols))
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
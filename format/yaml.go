@@ -0,0 +1,76 @@
+package format
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// yamlBinaryWidth is the number of base64 characters per line of !!binary
+// scalars.
+const yamlBinaryWidth = 76
+
+// lineWrapper writes to out, starting a new line with indent after every
+// width bytes.
+type lineWrapper struct {
+	out    io.Writer
+	indent string
+	width  int
+	col    int
+}
+
+func (w *lineWrapper) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if w.col == w.width {
+			io.WriteString(w.out, "\n")
+			w.col = 0
+		}
+		if w.col == 0 {
+			io.WriteString(w.out, w.indent)
+		}
+		chunk := p
+		if len(chunk) > w.width-w.col {
+			chunk = chunk[:w.width-w.col]
+		}
+		m, err := w.out.Write(chunk)
+		n += m
+		w.col += m
+		if err != nil {
+			return n, err
+		}
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// writeYAMLBinary writes in as a YAML !!binary block scalar.
+func writeYAMLBinary(out io.Writer, in io.Reader) error {
+	fmt.Fprint(out, "!!binary |\n")
+	wrapper := &lineWrapper{out: out, indent: "  ", width: yamlBinaryWidth}
+	enc := base64.NewEncoder(base64.StdEncoding, wrapper)
+	_, err := io.Copy(enc, in)
+	if err != nil {
+		return err
+	}
+	// Close flushes the final partial block.
+	return enc.Close()
+}
+
+// writeYAMLSequence writes in as a YAML flow sequence of byte values.
+func writeYAMLSequence(out io.Writer, in io.Reader) error {
+	fmt.Fprint(out, "[\n")
+	err := columnLayout{
+		format:  "%d",
+		sep:     ", ",
+		indent:  "  ",
+		lineSep: ",\n",
+		end:     "\n",
+		perLine: opts.lineWidth(16),
+	}.write(out, in)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(out, "]")
+	return nil
+}
@@ -0,0 +1,51 @@
+package format
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// z85Alphabet is the alphabet of the Z85 encoding as specified by ZeroMQ RFC
+// 32.
+const z85Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.-:+=^!/*?&<>()[]{}@%$#"
+
+// writeZ85 writes in encoded in Z85, which encodes every 4 input bytes as 5
+// characters. Z85 requires the input length to be a multiple of 4, if pad is
+// set a short last block is filled up with zero bytes instead of failing.
+func writeZ85(out io.Writer, in io.Reader, pad bool) error {
+	r := bufio.NewReaderSize(in, opts.BufferSize)
+	var block [4]byte
+	var encoded [5]byte
+	var total int64
+	for {
+		n, err := io.ReadFull(r, block[:])
+		total += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			if !pad {
+				return fmt.Errorf("z85 input length must be a multiple of 4, got %d bytes", total)
+			}
+			for i := n; i < len(block); i++ {
+				block[i] = 0
+			}
+		} else if err != nil {
+			return err
+		}
+
+		value := binary.BigEndian.Uint32(block[:])
+		for i := len(encoded) - 1; i >= 0; i-- {
+			encoded[i] = z85Alphabet[value%85]
+			value /= 85
+		}
+		out.Write(encoded[:])
+
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+	return nil
+}
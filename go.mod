@@ -2,4 +2,7 @@ module github.com/targodan/slice
 
 go 1.15
 
-require github.com/urfave/cli/v2 v2.2.0
+require (
+	github.com/urfave/cli/v2 v2.2.0
+	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad
+)
@@ -0,0 +1,15 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// mmapRange is not supported on this platform, callers fall back to reading.
+func mmapRange(file *os.File, offset, length int64) (io.Reader, func() error, error) {
+	return nil, nil, errors.New("mmap is not supported on this platform")
+}
@@ -0,0 +1,29 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"syscall"
+)
+
+// mmapRange maps length bytes of file starting at offset into memory and
+// returns a reader for them along with a function unmapping them again.
+func mmapRange(file *os.File, offset, length int64) (io.Reader, func() error, error) {
+	if length == 0 {
+		return bytes.NewReader(nil), func() error { return nil }, nil
+	}
+
+	// Mappings have to start at a page boundary.
+	start := offset - offset%int64(os.Getpagesize())
+	data, err := syscall.Mmap(int(file.Fd()), start, int(offset-start+length), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bytes.NewReader(data[offset-start:]), func() error {
+		return syscall.Munmap(data)
+	}, nil
+}
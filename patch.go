@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/targodan/slice/format"
+)
+
+// patchFile reads data from in, passes it through decode and writes the
+// result into the file called filename at offset. Negative offsets are
+// relative to the end of the file. Unless grow is set, writing beyond the end
+// of the file is an error.
+func patchFile(filename string, offset int64, in io.Reader, decode format.Formatter, grow bool) error {
+	var data bytes.Buffer
+	err := decode(&data, in)
+	if err != nil {
+		return fmt.Errorf("could not decode the patch, reason: %w", err)
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR, 0666)
+	if err != nil {
+		return fmt.Errorf("could not open file, reason: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("patching requires a regular file")
+	}
+	offset, err = resolveOffset(file, offset)
+	if err != nil {
+		return err
+	}
+	end := offset + int64(data.Len())
+	if end > info.Size() && !grow {
+		return fmt.Errorf("patch of 0x%X bytes at offset 0x%X extends beyond end of file (size 0x%X), use --grow to extend the file", data.Len(), offset, info.Size())
+	}
+
+	_, err = file.WriteAt(data.Bytes(), offset)
+	if err != nil {
+		return fmt.Errorf("could not write the patch, reason: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is the time between two progress reports.
+const progressInterval = 500 * time.Millisecond
+
+// progressReader counts the bytes read through it.
+type progressReader struct {
+	r io.Reader
+	// n is accessed atomically, as it is read by the reporting goroutine.
+	n int64
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	atomic.AddInt64(&p.n, int64(n))
+	return n, err
+}
+
+// humanBytes formats n bytes using binary prefixes.
+func humanBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	unit := 0
+	for n >= 1024 && unit < len(units)-1 {
+		n /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", n, units[unit])
+}
+
+// trackProgress replaces the reader of s such that the progress of reading it
+// is reported on stderr periodically. The returned function stops reporting
+// and must be called once s was formatted.
+func trackProgress(s *section) func() {
+	p := &progressReader{r: s.r}
+	s.r = p
+	length := s.length
+	start := time.Now()
+
+	report := func() {
+		n := atomic.LoadInt64(&p.n)
+		rate := float64(n) / time.Since(start).Seconds()
+		if length > 0 {
+			fmt.Fprintf(os.Stderr, "\r%5.1f%%  %s/s   ", 100*float64(n)/float64(length), humanBytes(rate))
+		} else {
+			fmt.Fprintf(os.Stderr, "\r%s  %s/s   ", humanBytes(float64(n)), humanBytes(rate))
+		}
+	}
+
+	ticker := time.NewTicker(progressInterval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+				report()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+		<-stopped
+		report()
+		fmt.Fprintln(os.Stderr)
+	}
+}
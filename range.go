@@ -0,0 +1,385 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// byteRange is a portion of the input. A size of -1 extends the range to the
+// end of the input.
+type byteRange struct {
+	offset int64
+	size   int64
+}
+
+// parseRange parses a range given as START:LEN or START-END, where END is
+// exclusive. Both positions are passed through resolve, which allows for
+// positions relative to the end of file.
+func parseRange(s string, resolve func(int64) (int64, error)) (byteRange, error) {
+	sep := strings.Index(s, ":")
+	if sep < 0 && len(s) > 0 {
+		// A leading "-" belongs to a negative start.
+		sep = strings.Index(s[1:], "-")
+		if sep >= 0 {
+			sep++
+		}
+	}
+	if sep < 0 {
+		return byteRange{}, fmt.Errorf("range \"%s\" is neither START:LEN nor START-END", s)
+	}
+
+	start, err := parseByteSize(s[:sep])
+	if err != nil {
+		return byteRange{}, fmt.Errorf("could not parse start of range \"%s\", reason: %w", s, err)
+	}
+	start, err = resolve(start)
+	if err != nil {
+		return byteRange{}, err
+	}
+	value, err := parseByteSize(s[sep+1:])
+	if err != nil {
+		return byteRange{}, fmt.Errorf("could not parse range \"%s\", reason: %w", s, err)
+	}
+
+	if s[sep] == ':' {
+		if value < 0 {
+			return byteRange{}, fmt.Errorf("length of range \"%s\" must not be negative", s)
+		}
+		return byteRange{offset: start, size: value}, nil
+	}
+
+	end, err := resolve(value)
+	if err != nil {
+		return byteRange{}, err
+	}
+	if end <= start {
+		return byteRange{}, fmt.Errorf("end of range \"%s\" must be greater than its start", s)
+	}
+	return byteRange{offset: start, size: end - start}, nil
+}
+
+// section is a part of an input that is formatted on its own.
+type section struct {
+	byteRange
+	r io.Reader
+	// length is the number of bytes r yields, or -1 if it is not known.
+	length int64
+	// release frees resources held by r, it may be nil.
+	release func() error
+}
+
+// mapped returns sec backed by a memory mapping of file if possible, and sec
+// unchanged otherwise.
+func mapped(file *os.File, sec section) section {
+	// The length is only known for regular files, which can be mapped.
+	if sec.length < 0 {
+		return sec
+	}
+	r, unmap, err := mmapRange(file, sec.offset, sec.length)
+	if err != nil {
+		return sec
+	}
+	sec.r = r
+	sec.release = unmap
+	return sec
+}
+
+// bitSection returns s reduced to size bits starting at bit skip of its first
+// byte, see newBitReader.
+func bitSection(s section, skip uint, size int64, rightAligned bool) section {
+	if s.length >= 0 && s.length*8-int64(skip) < size {
+		size = s.length*8 - int64(skip)
+		if size < 0 {
+			size = 0
+		}
+	}
+	s.r = newBitReader(s.r, skip, size, rightAligned)
+	if s.length >= 0 {
+		s.length = (size + 7) / 8
+	}
+	return s
+}
+
+// padSection returns s filled up to its size with fill bytes if it ends
+// early, see padReader. Sections without a size are returned as is.
+func padSection(s section, fill byte) section {
+	if s.size == -1 {
+		return s
+	}
+	s.r = &padReader{r: s.r, remaining: s.size, fill: fill}
+	s.length = s.size
+	return s
+}
+
+// swapSection returns s with the byte order reversed within every word of
+// wordSize bytes, see swapReader. If the length of s is known, it is checked
+// up front so that no output is written for inputs that cannot be swapped.
+func swapSection(s section, wordSize int, pad bool) (section, error) {
+	if s.length >= 0 && s.length%int64(wordSize) != 0 {
+		if !pad {
+			return section{}, fmt.Errorf("input length must be a multiple of the word size %d to swap, got %d bytes", wordSize, s.length)
+		}
+		s.length += int64(wordSize) - s.length%int64(wordSize)
+	}
+	s.r = newSwapReader(s.r, wordSize, pad)
+	return s, nil
+}
+
+// knownLength returns the number of bytes the range r of file consists of, or
+// -1 if the size of file is not known.
+func knownLength(file *os.File, r byteRange) int64 {
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return -1
+	}
+	length := info.Size() - r.offset
+	if r.size != -1 && r.size < length {
+		length = r.size
+	}
+	if length < 0 {
+		return 0
+	}
+	return length
+}
+
+// totalLength returns the sum of the lengths of sections, or -1 if the length
+// of any of them is not known.
+func totalLength(sections []section) int64 {
+	var length int64
+	for _, s := range sections {
+		if s.length < 0 {
+			return -1
+		}
+		length += s.length
+	}
+	return length
+}
+
+// combinedReader reads the sections of several files one after another. Every
+// file is only opened once the previous one has been read, so that a single
+// file is open at a time.
+type combinedReader struct {
+	// open opens a file and returns its sections and a function closing it.
+	open      func(filename string) ([]section, func(), error)
+	filenames []string
+	current   io.Reader
+	// closeCurrent closes the file current reads from.
+	closeCurrent func()
+}
+
+// next makes c continue with sections, which closeSections closes.
+func (c *combinedReader) next(sections []section, closeSections func()) {
+	readers := make([]io.Reader, len(sections))
+	for i, s := range sections {
+		readers[i] = s.r
+	}
+	c.current = io.MultiReader(readers...)
+	c.closeCurrent = closeSections
+}
+
+// close closes the file that is currently read.
+func (c *combinedReader) close() {
+	if c.closeCurrent != nil {
+		c.closeCurrent()
+		c.closeCurrent = nil
+	}
+	c.current = nil
+}
+
+func (c *combinedReader) Read(p []byte) (int, error) {
+	for {
+		if c.current != nil {
+			n, err := c.current.Read(p)
+			if err != io.EOF {
+				return n, err
+			}
+			c.close()
+			if n > 0 {
+				return n, nil
+			}
+		}
+		if len(c.filenames) == 0 {
+			return 0, io.EOF
+		}
+		sections, closeSections, err := c.open(c.filenames[0])
+		if err != nil {
+			return 0, err
+		}
+		c.filenames = c.filenames[1:]
+		c.next(sections, closeSections)
+	}
+}
+
+// selection describes the parts of an input selected on the command line.
+// Positions may still be relative to the end of file.
+type selection struct {
+	offset int64
+	// fromEnd makes offset count from the end of file.
+	fromEnd bool
+	size    int64
+	// end replaces size if it is not nil.
+	end    *int64
+	ranges []string
+	// strict turns truncated selections into an error.
+	strict bool
+	// mmap reads regular files through memory mappings.
+	mmap bool
+	// tarMember selects from the tar archive member of that name instead of
+	// the whole file if it is not empty.
+	tarMember string
+	// follow waits for data appended to the file instead of stopping at its
+	// end, see followReader.
+	follow bool
+	// pad fills sections that end early up to their size with the byte it
+	// points to, see padSection.
+	pad *byte
+}
+
+// beyondEnd handles err reporting that a range extends beyond the end of the
+// input. It is returned if strict is set, otherwise it is printed as a
+// warning on stderr. A nil err is returned as is.
+func (s selection) beyondEnd(err error) error {
+	if err == nil || s.strict {
+		return err
+	}
+	if s.pad != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: %v, output is padded\n", err)
+	} else {
+		fmt.Fprintf(os.Stderr, "WARNING: %v, output is truncated\n", err)
+	}
+	return nil
+}
+
+// resolve resolves the selection against file and returns the selected
+// ranges in order, without reading from file.
+func (s selection) resolve(file *os.File) ([]byteRange, error) {
+	resolve := func(pos int64) (int64, error) {
+		return resolveOffset(file, pos)
+	}
+
+	if len(s.ranges) > 0 {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("--range requires a regular file as input")
+		}
+
+		var ranges []byteRange
+		for _, value := range s.ranges {
+			r, err := parseRange(value, resolve)
+			if err != nil {
+				return nil, err
+			}
+			err = checkOffset(file, r.offset)
+			if err != nil {
+				return nil, err
+			}
+			ranges = append(ranges, r)
+		}
+		return ranges, nil
+	}
+
+	var offset int64
+	var err error
+	if s.fromEnd {
+		offset, err = offsetFromEnd(file, s.offset)
+	} else {
+		offset, err = resolve(s.offset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve offset, reason: %w", err)
+	}
+	err = checkOffset(file, offset)
+	if err != nil {
+		return nil, err
+	}
+	size := s.size
+	if s.end != nil {
+		end, err := resolve(*s.end)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve end, reason: %w", err)
+		}
+		if end <= offset {
+			return nil, fmt.Errorf("end 0x%X must be greater than offset 0x%X", end, offset)
+		}
+		size = end - offset
+	}
+	return []byteRange{{offset: offset, size: size}}, nil
+}
+
+// sections resolves the selection against file and returns the selected parts
+// in order.
+func (s selection) sections(file *os.File) ([]section, error) {
+	if s.tarMember != "" {
+		return s.tarSections(file)
+	}
+
+	ranges, err := s.resolve(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.ranges) > 0 {
+		var sections []section
+		for _, r := range ranges {
+			err = s.beyondEnd(checkSize(file, r))
+			if err != nil {
+				return nil, err
+			}
+			sec := section{
+				byteRange: r,
+				r:         io.NewSectionReader(file, r.offset, r.size),
+				length:    knownLength(file, r),
+			}
+			if s.mmap {
+				sec = mapped(file, sec)
+			}
+			sections = append(sections, sec)
+		}
+		return sections, nil
+	}
+
+	selected := ranges[0]
+	if !s.follow {
+		// A followed file is expected to grow.
+		err = s.beyondEnd(checkSize(file, selected))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = seekOrSkip(file, selected.offset)
+	if err != nil {
+		return nil, fmt.Errorf("could not seek to offset 0x%X, reason: %w", selected.offset, err)
+	}
+
+	var r io.Reader = file
+	length := knownLength(file, selected)
+	if s.follow {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("--follow requires a regular file as input")
+		}
+		pos, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		r = &followReader{file: file, pos: pos}
+		length = -1
+	}
+	if selected.size != -1 {
+		r = io.LimitReader(r, selected.size)
+	}
+	sec := section{byteRange: selected, r: r, length: length}
+	if s.mmap {
+		sec = mapped(file, sec)
+	}
+	return []section{sec}, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// repeatReader reads r to its end count times, seeking back to the start in
+// between.
+type repeatReader struct {
+	r     io.ReadSeeker
+	start int64
+	count int
+}
+
+func (rr *repeatReader) Read(p []byte) (int, error) {
+	for {
+		n, err := rr.r.Read(p)
+		if err != io.EOF || rr.count <= 1 {
+			return n, err
+		}
+		rr.count--
+		_, err = rr.r.Seek(rr.start, io.SeekStart)
+		if err != nil {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// repeated returns s with its contents repeated count times. Sections of
+// regular files are re-read, everything else is buffered once.
+func repeated(s section, count int) (section, error) {
+	var seeker io.ReadSeeker
+	// The length is only known for regular files, which can be re-read.
+	if s.length >= 0 {
+		switch r := s.r.(type) {
+		case io.ReadSeeker:
+			seeker = r
+		case *io.LimitedReader:
+			// The default selection limits the file, which has already been
+			// positioned at the start of the section.
+			if file, ok := r.R.(*os.File); ok {
+				pos, err := file.Seek(0, io.SeekCurrent)
+				if err == nil {
+					seeker = io.NewSectionReader(file, pos, r.N)
+				}
+			}
+		}
+	}
+	if seeker == nil {
+		data, err := ioutil.ReadAll(s.r)
+		if err != nil {
+			return section{}, err
+		}
+		seeker = bytes.NewReader(data)
+		s.length = int64(len(data))
+	}
+
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return section{}, err
+	}
+	s.r = &repeatReader{r: seeker, start: start, count: count}
+	if s.length >= 0 {
+		s.length *= int64(count)
+	}
+	return s, nil
+}
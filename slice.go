@@ -1,235 +1,1201 @@
 package main
 
 import (
-	"crypto/md5"
-	"crypto/sha256"
+	"bufio"
+	"bytes"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
+	"github.com/targodan/slice/format"
 	"github.com/urfave/cli/v2"
 )
 
-var allFormats = []string{
-	"raw",
-	"hex",
-	"dump",
-	"gobytes",
-	"gostring",
-	"cstring",
-	"base64",
-	"md5",
-	"sha256",
-}
-
-type formatter func(out io.Writer, in io.Reader) error
-
-func makeCPrintSafe(b byte) (string, bool) {
-	escaped, ok := map[byte]string{
-		'\a': "\\a",
-		'\b': "\\b",
-		'\f': "\\f",
-		'\n': "\\n",
-		'\r': "\\r",
-		'\t': "\\t",
-		'\v': "\\v",
-		'\\': "\\\\",
-		'"':  "\\\"",
-	}[b]
-	if ok {
-		return escaped, false
-	}
-
-	if 20 <= b && b <= 126 {
-		return fmt.Sprintf("%c", b), false
-	} else {
-		return fmt.Sprintf("\\x%02x", b), true
-	}
-}
-
-var formatters = map[string]formatter{
-	"raw": func(out io.Writer, in io.Reader) error {
-		_, err := io.Copy(out, in)
-		return err
-	},
-	"hex": func(out io.Writer, in io.Reader) error {
-		enc := hex.NewEncoder(out)
-		_, err := io.Copy(enc, in)
-		if err != nil {
-			return err
+// seekOrSkip advances file by offset bytes. Inputs that cannot seek, like
+// pipes, FIFOs and character devices, are advanced by discarding bytes.
+func seekOrSkip(file *os.File, offset int64) error {
+	info, err := file.Stat()
+	if err == nil && info.Mode().IsRegular() {
+		// Seeking relative to the current position keeps the semantics of
+		// skipping, which matters for stdin redirected from a file.
+		_, err = file.Seek(offset, io.SeekCurrent)
+		if err == nil {
+			return nil
 		}
-		out.Write([]byte{'\n'})
+	}
+
+	_, err = io.CopyN(ioutil.Discard, file, offset)
+	if err == io.EOF {
+		// Seeking past the end is not an error either.
 		return nil
-	},
-	"dump": func(out io.Writer, in io.Reader) error {
-		enc := hex.Dumper(out)
-		_, err := io.Copy(enc, in)
-		return err
-	},
-	"gobytes": func(out io.Writer, in io.Reader) error {
-		data, err := ioutil.ReadAll(in)
-		if err != nil {
-			return err
-		}
-		fmt.Printf("%#v\n", data)
+	}
+	return err
+}
+
+// resolveOffset converts a negative offset, which counts from the end of file,
+// into an absolute one.
+func resolveOffset(file *os.File, offset int64) (int64, error) {
+	if offset >= 0 {
+		return offset, nil
+	}
+	return offsetFromEnd(file, -offset)
+}
+
+// offsetFromEnd returns the absolute position n bytes before the end of file.
+func offsetFromEnd(file *os.File, n int64) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("offsets relative to the end require a regular file as input")
+	}
+	if n > info.Size() {
+		return 0, fmt.Errorf("offset of %d bytes from the end exceeds the file size of %d bytes", n, info.Size())
+	}
+	return info.Size() - n, nil
+}
+
+// checkOffset returns an error if offset lies beyond the end of file. Inputs
+// of unknown size are not checked.
+func checkOffset(file *os.File, offset int64) error {
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
 		return nil
-	},
-	"gostring": func(out io.Writer, in io.Reader) error {
-		data, err := ioutil.ReadAll(in)
-		if err != nil {
-			return err
-		}
-		fmt.Printf("%#v\n", string(data))
+	}
+	if offset > info.Size() {
+		return fmt.Errorf("offset 0x%X is beyond end of file (size 0x%X)", offset, info.Size())
+	}
+	return nil
+}
+
+// checkSize returns an error if r extends beyond the end of file, which would
+// silently truncate the output, see selection.beyondEnd. Inputs of unknown
+// size are not checked.
+func checkSize(file *os.File, r byteRange) error {
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() || r.size == -1 {
 		return nil
-	},
-	"cstring_unsafe": func(out io.Writer, in io.Reader) error {
-		fmt.Fprint(out, "\"")
-		var err error
-		var n int
-		var lastOutWasHex bool
-		buf := make([]byte, 512)
-		for err == nil {
-			n, err = in.Read(buf)
-
-			for _, b := range buf[:n] {
-				str, isHex := makeCPrintSafe(b)
-				if lastOutWasHex && !isHex {
-					// split string literal to avoid problems like this
-					// { 0x00, 'a' } -> "\x00a" could be parsed wrong
-					fmt.Fprint(out, "\" \"")
-				}
-				fmt.Fprint(out, str)
-				lastOutWasHex = isHex
-			}
+	}
+	if r.offset+r.size <= info.Size() {
+		return nil
+	}
+
+	return fmt.Errorf("range 0x%X+0x%X extends beyond end of file (size 0x%X)", r.offset, r.size, info.Size())
+}
+
+// exitEmpty is the exit code if the selection is empty.
+const exitEmpty = 2
+
+// opts are the options passed on to the formats.
+var opts = format.DefaultOptions()
+
+// formatNames returns the names of all advertised formats.
+func formatNames() []string {
+	var names []string
+	for _, info := range format.Formats() {
+		names = append(names, info.Name)
+	}
+	return names
+}
+
+// padValue is the value of the --pad flag, which may be given with or without
+// a fill byte. The fill byte is parsed by parseSelection.
+type padValue struct {
+	set   bool
+	value string
+}
+
+func (p *padValue) Set(value string) error {
+	p.set = true
+	p.value = value
+	return nil
+}
+
+func (p *padValue) String() string {
+	return ""
+}
+
+// IsBoolFlag allows --pad to be given without a value.
+func (p *padValue) IsBoolFlag() bool {
+	return true
+}
+
+// padFlag is the --pad flag, which is listed in the help as --pad[=BYTE] as
+// its value is optional.
+type padFlag struct {
+	*cli.GenericFlag
+}
+
+func (f padFlag) String() string {
+	return fmt.Sprintf("--%s[=BYTE]\t%s", f.Name, f.Usage)
+}
+
+// decodeKey decodes key given in keyFormat, which is one of raw, hex and
+// base64.
+func decodeKey(key, keyFormat string) ([]byte, error) {
+	var decoded []byte
+	var err error
+	switch keyFormat {
+	case "raw":
+		return []byte(key), nil
+	case "hex":
+		decoded, err = hex.DecodeString(key)
+	case "base64":
+		decoded, err = base64.StdEncoding.DecodeString(key)
+	default:
+		return nil, fmt.Errorf("unsupported key format \"%s\", expected raw, hex or base64", keyFormat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode %s key, reason: %w", keyFormat, err)
+	}
+	return decoded, nil
+}
+
+// isTerminal reports whether file is a terminal.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// isIdentifier reports whether s is a valid identifier in all languages
+// supported by the language literal formats.
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		isLetter := r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+		isDigit := '0' <= r && r <= '9'
+		if !isLetter && !(isDigit && i > 0) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// parseSelection builds the selection given by the flags of c.
+func parseSelection(c *cli.Context) (selection, error) {
+	offset, err := parseByteSize(c.String("offset"))
+	if err != nil {
+		return selection{}, fmt.Errorf("could not parse offset \"%s\", reason: %w", c.String("offset"), err)
+	}
+	size, err := parseByteSize(c.String("size"))
+	if err != nil {
+		return selection{}, fmt.Errorf("could not parse size \"%s\", reason: %w", c.String("size"), err)
+	}
+
+	sel := selection{
+		offset:    offset,
+		size:      size,
+		ranges:    c.StringSlice("range"),
+		strict:    c.Bool("strict"),
+		mmap:      c.Bool("mmap"),
+		follow:    c.Bool("follow"),
+		tarMember: c.String("tar-member"),
+	}
+	switch c.String("seek-from") {
+	case "start":
+	case "end":
+		if offset < 0 {
+			return selection{}, fmt.Errorf("offset must not be negative when seeking from the end")
 		}
-		if err != io.EOF {
-			return err
+		sel.fromEnd = true
+	default:
+		return selection{}, fmt.Errorf("unsupported seek origin \"%s\", expected start or end", c.String("seek-from"))
+	}
+	if c.IsSet("end") {
+		if c.IsSet("size") {
+			return selection{}, fmt.Errorf("--size and --end are mutually exclusive")
 		}
-		fmt.Fprint(out, "\"\n")
-		return nil
-	},
-	"cstring": func(out io.Writer, in io.Reader) error {
-		fmt.Fprint(out, "\"")
-		var err error
-		var n int
-		buf := make([]byte, 512)
-		for err == nil {
-			n, err = in.Read(buf)
-
-			for _, b := range buf[:n] {
-				fmt.Fprintf(out, "\\x%02x", b)
+		end, err := parseByteSize(c.String("end"))
+		if err != nil {
+			return selection{}, fmt.Errorf("could not parse end \"%s\", reason: %w", c.String("end"), err)
+		}
+		sel.end = &end
+	}
+	if c.IsSet("range") && (c.IsSet("offset") || c.IsSet("size") || c.IsSet("end")) {
+		return selection{}, fmt.Errorf("--range cannot be combined with --offset, --size or --end")
+	}
+	if pad, ok := c.Generic("pad").(*padValue); ok && pad.set {
+		var fill uint64
+		if pad.value != "true" {
+			fill, err = strconv.ParseUint(pad.value, 0, 8)
+			if err != nil {
+				return selection{}, fmt.Errorf("pad byte \"%s\" must be a number from 0 to 255", pad.value)
+			}
+		} else if first := c.Args().First(); first != "" {
+			// A byte given as --pad BYTE ends up as the first FILE.
+			_, numErr := strconv.ParseUint(first, 0, 8)
+			if _, statErr := os.Stat(first); numErr == nil && os.IsNotExist(statErr) {
+				return selection{}, fmt.Errorf("the pad byte must be given as --pad=%s", first)
 			}
 		}
-		if err != io.EOF {
-			return err
+		if sel.size == -1 && sel.end == nil && len(sel.ranges) == 0 {
+			return selection{}, fmt.Errorf("--pad requires --size, --end or --range")
 		}
-		fmt.Fprint(out, "\"\n")
-		return nil
-	},
-	"base64": func(out io.Writer, in io.Reader) error {
-		enc := base64.NewEncoder(base64.StdEncoding, out)
-		_, err := io.Copy(enc, in)
-		if err != nil {
-			return err
+		if sel.strict {
+			return selection{}, fmt.Errorf("--pad and --strict are mutually exclusive")
+		}
+		b := byte(fill)
+		sel.pad = &b
+	}
+	return sel, nil
+}
+
+// writeCount discards in and writes the number of bytes it yielded.
+func writeCount(out io.Writer, in io.Reader) error {
+	n, err := io.Copy(ioutil.Discard, in)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(out, n)
+	if !opts.NoNewline {
+		fmt.Fprintln(out)
+	}
+	return nil
+}
+
+// writeSection formats the section s.
+func writeSection(out io.Writer, fmtter format.Formatter, s section) error {
+	opts.Offset = s.offset
+	format.SetOptions(opts)
+
+	err := fmtter(out, s.r)
+	if err != nil {
+		return fmt.Errorf("an error occured during reading of the file: %w", err)
+	}
+	return nil
+}
+
+// writeLabelledDigest formats the section s with the digest formatter fmtter
+// and writes the result followed by name like sha256sum does.
+func writeLabelledDigest(out io.Writer, fmtter format.Formatter, s section, name string) error {
+	var digest bytes.Buffer
+	err := writeSection(&digest, fmtter, s)
+	if err != nil {
+		return err
+	}
+	// Like coreutils, names with special characters are escaped and marked
+	// by a leading backslash.
+	escaped := strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(name)
+	if escaped != name {
+		out.Write([]byte{'\\'})
+	}
+	fmt.Fprintf(out, "%s  %s\n", strings.TrimSuffix(digest.String(), "\n"), escaped)
+	return nil
+}
+
+// expandDirectories replaces all directories in filenames by the regular
+// files they contain, recursively and in lexical order.
+func expandDirectories(filenames []string) ([]string, error) {
+	var expanded []string
+	for _, filename := range filenames {
+		info, err := os.Stat(filename)
+		if filename == "-" || isURL(filename) || err == nil && !info.IsDir() {
+			expanded = append(expanded, filename)
+			continue
 		}
-		out.Write([]byte{'\n'})
-		return nil
-	},
-	"md5": func(out io.Writer, in io.Reader) error {
-		enc := md5.New()
-		_, err := io.Copy(enc, in)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("could not open file, reason: %w", err)
 		}
-		hex.NewEncoder(out).Write(enc.Sum(nil))
-		out.Write([]byte{'\n'})
-		return nil
-	},
-	"sha256": func(out io.Writer, in io.Reader) error {
-		enc := sha256.New()
-		_, err := io.Copy(enc, in)
+		err = filepath.Walk(filename, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() {
+				expanded = append(expanded, path)
+			}
+			return nil
+		})
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("could not read directory, reason: %w", err)
 		}
-		hex.NewEncoder(out).Write(enc.Sum(nil))
-		out.Write([]byte{'\n'})
-		return nil
-	},
+	}
+	return expanded, nil
+}
+
+// verifyDigest formats the section s with the digest formatter fmtter and
+// reports whether the result matches expected, ignoring case.
+func verifyDigest(fmtter format.Formatter, s section, expected string) (bool, error) {
+	var digest bytes.Buffer
+	err := writeSection(&digest, fmtter, s)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(strings.TrimSpace(digest.String()), strings.TrimSpace(expected)), nil
 }
 
 func main() {
 	app := &cli.App{
-		Name:      "slice",
-		Usage:     "outputs contents of binary files",
-		UsageText: "slice [options] FILE",
-		Writer:    os.Stderr,
-		ErrWriter: os.Stderr,
+		Name:        "slice",
+		Usage:       "outputs contents of binary files",
+		UsageText:   "slice [options] [FILE...]\n   slice formats\n   slice [options] diff FILE1 FILE2",
+		Description: "reads from stdin if FILE is omitted or -, FILE may also be an HTTP(S) URL, multiple files are sliced one after another",
+		Writer:      os.Stderr,
+		ErrWriter:   os.Stderr,
+		Commands: []*cli.Command{
+			{
+				Name:      "diff",
+				Usage:     "compares the selected bytes of two files and shows the first difference",
+				ArgsUsage: "FILE1 FILE2",
+				Action:    diffCommand,
+			},
+			{
+				Name:  "formats",
+				Usage: "lists the available output formats",
+				Action: func(c *cli.Context) error {
+					w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+					fmt.Fprintln(w, "NAME\tDESCRIPTION")
+					for _, info := range format.Formats() {
+						fmt.Fprintf(w, "%s\t%s\n", info.Name, info.Description)
+					}
+					return w.Flush()
+				},
+			},
+		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "offset",
 				Aliases: []string{"o"},
-				Usage:   "offset of output in bytes",
+				Usage:   "offset of output in bytes, negative values count from the end of file, accepts 0x, 0o and 0b prefixes and the suffixes KiB, MiB, GiB, TiB (1024-based), KB, MB, GB, TB (1000-based) and K, M, G, T (see --unit-base)",
 				Value:   "0",
 			},
+			&cli.StringFlag{
+				Name:  "seek-from",
+				Usage: "origin of --offset, one of start, end",
+				Value: "start",
+			},
 			&cli.StringFlag{
 				Name:    "size",
 				Aliases: []string{"length", "s", "l"},
-				Usage:   "size of output in bytes",
+				Usage:   "size of output in bytes, accepts 0x, 0o and 0b prefixes and the suffixes KiB, MiB, GiB, TiB (1024-based), KB, MB, GB, TB (1000-based) and K, M, G, T (see --unit-base)",
 				Value:   "-1",
 			},
+			&cli.StringFlag{
+				Name:    "end",
+				Aliases: []string{"e"},
+				Usage:   "end of output in bytes (exclusive) as an alternative to --size, accepts the same values as --offset",
+			},
+			&cli.IntFlag{
+				Name:  "swap",
+				Usage: "reverse the byte order within every `N` byte word, one of 2, 4, 8",
+			},
+			&cli.StringFlag{
+				Name:  "xor",
+				Usage: "XOR the selected bytes with `KEY` given in hex, the key is repeated as needed and starts anew with every range, bytes added by --pad are not XORed",
+			},
+			&cli.BoolFlag{
+				Name:    "reverse",
+				Aliases: []string{"reverse-bytes"},
+				Usage:   "reverse the order of the selected bytes, buffers every range in memory",
+			},
+			&cli.StringFlag{
+				Name:  "reverse-limit",
+				Usage: "largest range in bytes that --reverse buffers, accepts the same values as --size",
+				Value: "256MiB",
+			},
+			&cli.IntFlag{
+				Name:  "repeat",
+				Usage: "output the selected bytes `N` times",
+				Value: 1,
+			},
+			&cli.StringFlag{
+				Name:  "bit-offset",
+				Usage: "start of output in bits, selects a bit field together with --bit-size",
+				Value: "0",
+			},
+			&cli.StringFlag{
+				Name:  "bit-size",
+				Usage: "size of output in bits, the bits are packed into bytes most significant bit first",
+			},
+			&cli.StringFlag{
+				Name:  "bit-align",
+				Usage: "alignment of bit fields that do not fill the last byte, one of left, right",
+				Value: "left",
+			},
+			&cli.StringFlag{
+				Name:  "input-hex",
+				Usage: "read the input from the hex encoded `STRING` instead of FILE",
+			},
+			&cli.StringFlag{
+				Name:  "input-base64",
+				Usage: "read the input from the base64 encoded `STRING` instead of FILE",
+			},
+			&cli.StringFlag{
+				Name:  "tar-member",
+				Usage: "select from the member `NAME` of a tar archive, offsets are relative to the member",
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "fail instead of warning if the selected size extends beyond the end of file",
+			},
+			padFlag{&cli.GenericFlag{
+				Name:  "pad",
+				Usage: "fill the output up to the selected size with BYTE if the input ends early, BYTE defaults to 0x00 and must be attached with =",
+				Value: &padValue{},
+			}},
+			&cli.StringSliceFlag{
+				Name:  "range",
+				Usage: "range to output as START:LEN or START-END, may be repeated to output several ranges",
+			},
 			&cli.StringFlag{
 				Name:    "format",
 				Aliases: []string{"f"},
-				Usage:   "output format, available: " + strings.Join(allFormats, ", "),
+				Usage:   "output format, available: " + strings.Join(formatNames(), ", "),
 				Value:   "raw",
 			},
+			&cli.StringFlag{
+				Name:  "template",
+				Usage: "format every byte with the Go text/template `TEXT` instead of a format, fields: .Offset, .Byte, .Hex, .Char",
+			},
+			&cli.BoolFlag{
+				Name:  "template-rows",
+				Usage: "execute --template for every row of --width bytes, fields: .Offset, .Bytes, .Hex, .ASCII",
+			},
+			&cli.BoolFlag{
+				Name:    "decode",
+				Aliases: []string{"d", "decompress"},
+				Usage:   "decode input given in the selected format (hex, dump, xxd, base64, base64url, base32, ascii85, gzip), dump and xxd accept hexdump -C output as well instead of encoding it",
+			},
+			&cli.BoolFlag{
+				Name:  "write",
+				Usage: "patch FILE at --offset with the bytes read from stdin, decoded if --decode is given",
+			},
+			&cli.BoolFlag{
+				Name:  "grow",
+				Usage: "allow --write to extend the file",
+			},
+			&cli.BoolFlag{
+				Name:  "explain",
+				Usage: "print the resolved offsets and sizes, the format and the output to stderr without reading any data",
+			},
+			&cli.BoolFlag{
+				Name:  "count",
+				Usage: "output the number of selected bytes instead of their contents",
+			},
+			&cli.BoolFlag{
+				Name:    "follow",
+				Aliases: []string{"F"},
+				Usage:   "keep waiting for data appended to FILE after reaching its end, like tail -f",
+			},
+			&cli.BoolFlag{
+				Name:  "mmap",
+				Usage: "read regular files through a memory mapping",
+			},
+			&cli.BoolFlag{
+				Name:  "progress",
+				Usage: "periodically report progress on stderr",
+			},
+			&cli.BoolFlag{
+				Name:  "manifest",
+				Usage: "label every digest with its file name like sha256sum does, directories are hashed recursively (digest formats)",
+			},
+			&cli.BoolFlag{
+				Name:  "combined",
+				Usage: "format the slices of all files as a single stream, e.g. to compute one digest",
+			},
+			&cli.StringFlag{
+				Name:  "verify",
+				Usage: "compare the digest against `EXPECTED` and exit non-zero on a mismatch (digest formats)",
+			},
+			&cli.BoolFlag{
+				Name:  "list-formats",
+				Usage: "print the available output formats and exit",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"O"},
+				Usage:   "write output to `FILE` instead of stdout",
+			},
+			&cli.BoolFlag{
+				Name:    "no-newline",
+				Aliases: []string{"n"},
+				Usage:   "omit the trailing newline of text formats",
+			},
+			&cli.StringFlag{
+				Name:  "prefix",
+				Usage: "write `TEXT` in front of the output of text formats",
+			},
+			&cli.StringFlag{
+				Name:  "suffix",
+				Usage: "write `TEXT` after the output of text formats, in front of the trailing newline",
+			},
+			&cli.BoolFlag{
+				Name:    "upper",
+				Aliases: []string{"U"},
+				Usage:   "use uppercase hex digits (hex, dump and checksum formats)",
+			},
+			&cli.IntFlag{
+				Name:  "group",
+				Usage: "insert the separator every `N` bytes (hex)",
+			},
+			&cli.StringFlag{
+				Name:  "sep",
+				Usage: "separator inserted between groups (hex)",
+				Value: " ",
+			},
+			&cli.StringFlag{
+				Name:  "highlight",
+				Usage: "highlight the bytes at the positions `START:LEN` or START-END in the output (dump)",
+			},
+			&cli.StringFlag{
+				Name:  "color",
+				Usage: "color bytes by class (null, whitespace, printable, other ASCII, non-ASCII) in dump output, one of auto, always, never, auto colors if stdout is a terminal",
+				Value: "auto",
+			},
+			&cli.BoolFlag{
+				Name:  "no-ascii",
+				Usage: "omit the ASCII column of hex dumps (dump, xxd)",
+			},
+			&cli.StringFlag{
+				Name:  "offset-base",
+				Usage: "base of the offset column of dumps (dump), one of hex, dec",
+				Value: "hex",
+			},
+			&cli.IntFlag{
+				Name:  "min-len",
+				Usage: "minimum length of extracted strings (strings)",
+				Value: 4,
+			},
+			&cli.BoolFlag{
+				Name:  "with-offset",
+				Usage: "prefix extracted strings with their offset (strings)",
+			},
+			&cli.IntFlag{
+				Name:  "offset-width",
+				Usage: "minimum number of hex digits of offsets (offsets)",
+				Value: 4,
+			},
+			&cli.BoolFlag{
+				Name:  "char",
+				Usage: "also show the ASCII character of every byte, . if it is not printable (offsets)",
+			},
+			&cli.BoolFlag{
+				Name:  "repair",
+				Usage: "output the input with invalid sequences replaced by U+FFFD instead of checking it (utf8check)",
+			},
+			&cli.IntFlag{
+				Name:  "window",
+				Usage: "compute one value per `N` bytes (entropy)",
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "only show the `N` most frequent byte values (histogram)",
+			},
+			&cli.StringFlag{
+				Name:  "endian",
+				Usage: "byte order of the input (utf16), one of le, be",
+				Value: "le",
+			},
+			&cli.IntFlag{
+				Name:  "unit-base",
+				Usage: "base of the size suffixes K, M, G and T, either 1024 or 1000",
+				Value: 1024,
+			},
+			&cli.StringFlag{
+				Name:  "buffer-size",
+				Usage: "number of bytes read at once by streaming formats, accepts the same values as --size",
+				Value: "64KiB",
+			},
+			&cli.IntFlag{
+				Name:  "hash-size",
+				Usage: "digest size in bits for formats that support it (blake2b)",
+				Value: 512,
+			},
+			&cli.StringFlag{
+				Name:  "hmac-key",
+				Usage: "compute an HMAC with `KEY` instead of a plain digest (md5, sha1, sha256, sha512)",
+			},
+			&cli.StringFlag{
+				Name:  "key-format",
+				Usage: "encoding of --hmac-key, one of raw, hex, base64",
+				Value: "raw",
+			},
+			&cli.IntFlag{
+				Name:  "hash-truncate",
+				Usage: "only output the first `N` hex characters of digests, 0 outputs all of them",
+			},
+			&cli.BoolFlag{
+				Name:  "no-padding",
+				Usage: "omit padding of base32, base64 and base64url output",
+			},
+			&cli.StringFlag{
+				Name:  "spec",
+				Usage: "fields read by the struct format as comma separated `TYPE:NAME` pairs, TYPE is u8, i8 or u16, i16, u32, i32, u64, i64, f32, f64 followed by le or be",
+			},
+			&cli.BoolFlag{
+				Name:  "csv-hex",
+				Usage: "write hex instead of decimal values (csv)",
+			},
+			&cli.BoolFlag{
+				Name:  "yaml-binary",
+				Usage: "emit a base64 encoded !!binary scalar instead of a sequence (yaml)",
+			},
+			&cli.BoolFlag{
+				Name:  "zero-pad",
+				Usage: "fill up an incomplete last block with zero bytes instead of failing (z85, --swap)",
+			},
+			&cli.IntFlag{
+				Name:    "width",
+				Aliases: []string{"w"},
+				Usage:   "bytes per line for line based formats, 0 uses the default of the format",
+			},
+			&cli.BoolFlag{
+				Name:  "fixed-size",
+				Usage: "emit a fixed size array instead of a slice (rust), buffers the whole input",
+			},
+			&cli.BoolFlag{
+				Name:  "keep-unreserved",
+				Usage: "only escape bytes that are not unreserved characters (urlencode)",
+			},
+			&cli.StringFlag{
+				Name:    "var-name",
+				Aliases: []string{"ident"},
+				Usage:   "identifier emitted by language literal formats, defaults to data (DATA for rust) where a name is required",
+			},
+			&cli.BoolFlag{
+				Name:  "with-length",
+				Usage: "emit a length constant after the array (carray)",
+			},
+			&cli.IntFlag{
+				Name:  "address-bits",
+				Usage: "address width of record based formats (srec), one of 16, 24, 32",
+				Value: 32,
+			},
 		},
 		Action: func(c *cli.Context) error {
-			var offset, size int64
-
-			_, err := fmt.Sscanf(c.String("offset"), "%v", &offset)
-			if err != nil {
-				return fmt.Errorf("could not parse offset, reason: %w", err)
+			if err := setUnitBase(c); err != nil {
+				return err
 			}
-			_, err = fmt.Sscanf(c.String("size"), "%v", &size)
-			if err != nil {
-				return fmt.Errorf("could not parse offset, reason: %w", err)
+			if c.Bool("list-formats") {
+				for _, name := range formatNames() {
+					fmt.Println(name)
+				}
+				return nil
 			}
 
-			fmtter, ok := formatters[c.String("format")]
+			info, ok := format.Lookup(c.String("format"))
 			if !ok {
 				return fmt.Errorf("unsupported formatter \"%s\"", c.String("format"))
 			}
+			fmtter := format.Formatter(info.Format)
+			if c.Bool("decode") {
+				if !info.CanDecode() {
+					return fmt.Errorf("the format \"%s\" cannot be decoded", info.Name)
+				}
+				fmtter = info.Decode
+			}
+			if c.Bool("count") {
+				if c.Bool("decode") || c.IsSet("verify") || c.Bool("manifest") {
+					return fmt.Errorf("--count cannot be combined with --decode, --verify or --manifest")
+				}
+				fmtter = writeCount
+			}
+			if c.IsSet("template") {
+				if c.IsSet("format") || c.Bool("decode") || c.Bool("count") {
+					return fmt.Errorf("--template cannot be combined with --format, --decode or --count")
+				}
+				rowBytes := 0
+				if c.Bool("template-rows") {
+					rowBytes = 16
+					if c.Int("width") > 0 {
+						rowBytes = c.Int("width")
+					}
+				}
+				templateFmtter, err := format.TemplateFormatter(c.String("template"), rowBytes)
+				if err != nil {
+					return err
+				}
+				fmtter = templateFmtter
+			}
 
-			if c.NArg() != 1 {
-				return fmt.Errorf("expected exactly one argument, got %d", c.NArg())
+			bufferSize, err := parseByteSize(c.String("buffer-size"))
+			if err != nil {
+				return fmt.Errorf("could not parse buffer size \"%s\", reason: %w", c.String("buffer-size"), err)
+			}
+			if bufferSize < 1 || bufferSize > math.MaxInt32 {
+				return fmt.Errorf("buffer size must be positive and at most %d, got %d", math.MaxInt32, bufferSize)
+			}
+			opts.BufferSize = int(bufferSize)
+			if c.Int("hash-size")%8 != 0 {
+				return fmt.Errorf("hash size must be a multiple of 8 bits, got %d", c.Int("hash-size"))
+			}
+			opts.HashSize = c.Int("hash-size") / 8
+			if c.IsSet("hmac-key") {
+				if !info.SupportsHMAC() {
+					return fmt.Errorf("the format \"%s\" does not support HMAC", info.Name)
+				}
+				opts.HMACKey, err = decodeKey(c.String("hmac-key"), c.String("key-format"))
+				if err != nil {
+					return err
+				}
+			}
+			if c.Int("hash-truncate") < 0 {
+				return fmt.Errorf("hash truncation must not be negative, got %d", c.Int("hash-truncate"))
+			}
+			opts.HashTruncate = c.Int("hash-truncate")
+			opts.NoPadding = c.Bool("no-padding")
+			opts.ZeroPad = c.Bool("zero-pad")
+			opts.YAMLBinary = c.Bool("yaml-binary")
+			opts.CSVHex = c.Bool("csv-hex")
+			opts.Spec = c.String("spec")
+			if c.Int("width") < 0 {
+				return fmt.Errorf("width must not be negative, got %d", c.Int("width"))
+			}
+			opts.Width = c.Int("width")
+			opts.FixedSize = c.Bool("fixed-size")
+			opts.WithLength = c.Bool("with-length")
+			if c.String("var-name") != "" && !isIdentifier(c.String("var-name")) {
+				return fmt.Errorf("invalid variable name \"%s\", expected letters, digits and underscores not starting with a digit", c.String("var-name"))
+			}
+			opts.VarName = c.String("var-name")
+			opts.AddressBits = c.Int("address-bits")
+			opts.Upper = c.Bool("upper")
+			opts.NoNewline = c.Bool("no-newline")
+			opts.Prefix = c.String("prefix")
+			opts.Suffix = c.String("suffix")
+			opts.KeepUnreserved = c.Bool("keep-unreserved")
+			if c.Int("min-len") < 1 {
+				return fmt.Errorf("minimum string length must be positive, got %d", c.Int("min-len"))
+			}
+			opts.MinLen = c.Int("min-len")
+			opts.WithOffset = c.Bool("with-offset")
+			if c.Int("offset-width") < 0 {
+				return fmt.Errorf("offset width must not be negative, got %d", c.Int("offset-width"))
+			}
+			opts.OffsetDigits = c.Int("offset-width")
+			opts.Char = c.Bool("char")
+			opts.Repair = c.Bool("repair")
+			if c.Int("window") < 0 {
+				return fmt.Errorf("window must not be negative, got %d", c.Int("window"))
+			}
+			opts.Window = c.Int("window")
+			if c.Int("top") < 0 {
+				return fmt.Errorf("top must not be negative, got %d", c.Int("top"))
+			}
+			opts.Top = c.Int("top")
+			if c.Int("group") < 0 {
+				return fmt.Errorf("group must not be negative, got %d", c.Int("group"))
+			}
+			opts.Group = c.Int("group")
+			opts.Sep = c.String("sep")
+			opts.NoASCII = c.Bool("no-ascii")
+			switch c.String("color") {
+			case "auto":
+				opts.Color = !c.IsSet("output") && isTerminal(os.Stdout)
+			case "always":
+				opts.Color = true
+			case "never":
+				opts.Color = false
+			default:
+				return fmt.Errorf("unsupported color mode \"%s\", expected auto, always or never", c.String("color"))
+			}
+			if c.IsSet("highlight") {
+				highlight, err := parseRange(c.String("highlight"), func(pos int64) (int64, error) {
+					if pos < 0 {
+						return 0, fmt.Errorf("highlighted positions must not be negative")
+					}
+					return pos, nil
+				})
+				if err != nil {
+					return err
+				}
+				opts.HighlightOffset = highlight.offset
+				opts.HighlightSize = highlight.size
+			}
+			switch c.String("endian") {
+			case "le":
+				opts.BigEndian = false
+			case "be":
+				opts.BigEndian = true
+			default:
+				return fmt.Errorf("unsupported endianness \"%s\", expected le or be", c.String("endian"))
+			}
+			switch c.String("offset-base") {
+			case "hex":
+				opts.DecimalOffsets = false
+			case "dec":
+				opts.DecimalOffsets = true
+			default:
+				return fmt.Errorf("unsupported offset base \"%s\", expected hex or dec", c.String("offset-base"))
 			}
-			filename := c.Args().Get(0)
-			file, err := os.OpenFile(filename, os.O_RDONLY, 0666)
+
+			sel, err := parseSelection(c)
 			if err != nil {
-				return fmt.Errorf("could not open file, reason: %w", err)
+				return err
+			}
+
+			bits := c.IsSet("bit-offset") || c.IsSet("bit-size")
+			var bitOffset, bitSize int64
+			var rightAligned bool
+			if bits {
+				if !c.IsSet("bit-size") {
+					return fmt.Errorf("--bit-offset requires --bit-size")
+				}
+				if c.IsSet("offset") || c.IsSet("size") || c.IsSet("end") || c.IsSet("range") || c.IsSet("seek-from") {
+					return fmt.Errorf("--bit-offset and --bit-size cannot be combined with other selection flags")
+				}
+				bitOffset, err = parseByteSize(c.String("bit-offset"))
+				if err != nil {
+					return fmt.Errorf("could not parse bit offset \"%s\", reason: %w", c.String("bit-offset"), err)
+				}
+				bitSize, err = parseByteSize(c.String("bit-size"))
+				if err != nil {
+					return fmt.Errorf("could not parse bit size \"%s\", reason: %w", c.String("bit-size"), err)
+				}
+				if bitOffset < 0 || bitSize < 0 {
+					return fmt.Errorf("bit offset and size must not be negative")
+				}
+				switch c.String("bit-align") {
+				case "left":
+				case "right":
+					rightAligned = true
+				default:
+					return fmt.Errorf("unsupported bit alignment \"%s\", expected left or right", c.String("bit-align"))
+				}
+				sel.offset = bitOffset / 8
+				sel.size = (bitOffset%8 + bitSize + 7) / 8
+			}
+
+			swap := c.Int("swap")
+			switch swap {
+			case 0, 2, 4, 8:
+			default:
+				return fmt.Errorf("unsupported swap word size %d, expected 2, 4 or 8", swap)
+			}
+
+			var xorKey []byte
+			if c.IsSet("xor") {
+				xorKey, err = hex.DecodeString(strings.Join(strings.Fields(c.String("xor")), ""))
+				if err != nil {
+					return fmt.Errorf("could not parse xor key \"%s\", reason: %w", c.String("xor"), err)
+				}
+				if len(xorKey) == 0 {
+					return fmt.Errorf("xor key must not be empty")
+				}
 			}
-			defer file.Close()
 
-			_, err = file.Seek(offset, io.SeekStart)
+			if c.Bool("write") {
+				if c.IsSet("size") || c.IsSet("end") || c.IsSet("range") || c.IsSet("seek-from") {
+					return fmt.Errorf("--write only supports --offset to select the position")
+				}
+				if c.Bool("count") || c.IsSet("verify") || c.Bool("combined") || c.IsSet("repeat") || c.IsSet("xor") || c.IsSet("swap") || c.Bool("reverse") || c.IsSet("tar-member") || c.Bool("follow") || bits {
+					return fmt.Errorf("--write cannot be combined with --count, --verify, --combined, --repeat, --xor, --swap, --reverse, --tar-member, --follow or bit fields")
+				}
+				if c.IsSet("input-hex") || c.IsSet("input-base64") {
+					return fmt.Errorf("--write reads the patch from stdin and cannot be combined with --input-hex or --input-base64")
+				}
+				decode := fmtter
+				if !c.Bool("decode") {
+					if info.Name != "raw" {
+						return fmt.Errorf("--write requires --decode for the format \"%s\"", info.Name)
+					}
+					decode = info.Format
+				}
+				if c.NArg() != 1 || c.Args().First() == "-" {
+					return fmt.Errorf("--write requires exactly one FILE")
+				}
+				if c.Bool("explain") {
+					// Nothing is written, the patch is not even read.
+					fmt.Fprintf(os.Stderr, "write: %s at offset 0x%X (%d)\n", c.Args().First(), sel.offset, sel.offset)
+					if c.Bool("decode") {
+						fmt.Fprintf(os.Stderr, "patch: stdin decoded from %s\n", info.Name)
+					} else {
+						fmt.Fprintln(os.Stderr, "patch: stdin")
+					}
+					return nil
+				}
+				return patchFile(c.Args().First(), sel.offset, os.Stdin, decode, c.Bool("grow"))
+			}
+			if c.Bool("grow") {
+				return fmt.Errorf("--grow requires --write")
+			}
+
+			if sel.follow {
+				if info.Digest || c.Bool("count") || c.IsSet("verify") {
+					return fmt.Errorf("--follow requires a streaming format and cannot be combined with --count or --verify")
+				}
+				if c.IsSet("range") || c.Bool("combined") || c.Bool("mmap") || c.IsSet("repeat") || c.Bool("reverse") || c.IsSet("tar-member") {
+					return fmt.Errorf("--follow cannot be combined with --range, --combined, --mmap, --repeat, --reverse or --tar-member")
+				}
+				if c.NArg() > 1 {
+					return fmt.Errorf("--follow supports a single FILE only")
+				}
+			}
+
+			repeat := c.Int("repeat")
+			if repeat < 1 {
+				return fmt.Errorf("repeat count must be positive, got %d", repeat)
+			}
+			reverseLimit, err := parseByteSize(c.String("reverse-limit"))
 			if err != nil {
-				return fmt.Errorf("could not seek to offset 0x%X, reason: %w", offset, err)
+				return fmt.Errorf("could not parse reverse limit \"%s\", reason: %w", c.String("reverse-limit"), err)
+			}
+
+			if c.Bool("explain") {
+				mode := "format"
+				if c.Bool("decode") {
+					mode = "decode"
+				} else if c.Bool("count") {
+					mode = "count"
+				}
+				fmt.Fprintf(os.Stderr, "%s: %s\n", mode, info.Name)
+				if c.IsSet("output") {
+					fmt.Fprintf(os.Stderr, "output: %s\n", c.String("output"))
+				} else {
+					fmt.Fprintln(os.Stderr, "output: stdout")
+				}
+				if c.IsSet("input-hex") || c.IsSet("input-base64") {
+					fmt.Fprintf(os.Stderr, "inline data: offset 0x%X (%d)\n", sel.offset, sel.offset)
+					return nil
+				}
+				filenames := c.Args().Slice()
+				if len(filenames) == 0 {
+					filenames = []string{"-"}
+				}
+				return explain(os.Stderr, sel, filenames)
 			}
 
-			var in io.Reader
-			if size != -1 {
-				in = io.LimitReader(file, size)
+			dest := os.Stdout
+			if c.IsSet("output") {
+				dest, err = os.Create(c.String("output"))
+				if err != nil {
+					return fmt.Errorf("could not create output file, reason: %w", err)
+				}
+				defer dest.Close()
+			}
+			out := bufio.NewWriter(dest)
+			// Flushing is deferred as well, so that output written before an
+			// error is not lost.
+			defer out.Flush()
+
+			filenames := c.Args().Slice()
+			if len(filenames) == 0 {
+				filenames = []string{"-"}
+			}
+
+			var inline []byte
+			if c.IsSet("input-hex") || c.IsSet("input-base64") {
+				if c.IsSet("input-hex") && c.IsSet("input-base64") {
+					return fmt.Errorf("--input-hex and --input-base64 are mutually exclusive")
+				}
+				if c.NArg() > 0 {
+					return fmt.Errorf("inline input cannot be combined with FILE")
+				}
+				if c.IsSet("input-hex") {
+					inline, err = hex.DecodeString(strings.Join(strings.Fields(c.String("input-hex")), ""))
+				} else {
+					inline, err = base64.StdEncoding.DecodeString(c.String("input-base64"))
+				}
+				if err != nil {
+					return fmt.Errorf("could not decode inline input, reason: %w", err)
+				}
+				if inline == nil {
+					inline = []byte{}
+				}
+			}
+
+			if c.Bool("manifest") {
+				if !info.Digest {
+					return fmt.Errorf("--manifest requires a digest format, got \"%s\"", info.Name)
+				}
+				if c.IsSet("verify") || c.Bool("combined") {
+					return fmt.Errorf("--manifest cannot be combined with --verify or --combined")
+				}
+				filenames, err = expandDirectories(filenames)
+				if err != nil {
+					return err
+				}
+			}
+			if c.IsSet("verify") && !info.Digest {
+				return fmt.Errorf("--verify requires a digest format, got \"%s\"", info.Name)
+			}
+			var mismatch bool
+			// emit formats s, name is empty if s is not from a single file.
+			// selected is the number of bytes read from all sections.
+			var selected int64
+			emit := func(s section, name string) error {
+				counter := &progressReader{r: s.r}
+				s.r = counter
+				defer func() {
+					selected += counter.n
+				}()
+				if c.Bool("progress") {
+					stop := trackProgress(&s)
+					defer stop()
+				}
+				if c.IsSet("verify") {
+					ok, err := verifyDigest(fmtter, s, c.String("verify"))
+					if err != nil {
+						return err
+					}
+					status := "OK"
+					if !ok {
+						status = "FAILED"
+						mismatch = true
+					}
+					if len(filenames) > 1 && name != "" {
+						fmt.Fprintf(out, "%s: %s\n", name, status)
+					} else {
+						fmt.Fprintln(out, status)
+					}
+					return nil
+				}
+				if info.Digest && !c.Bool("count") && (len(filenames) > 1 || c.Bool("manifest")) && name != "" {
+					return writeLabelledDigest(out, fmtter, s, name)
+				}
+				if sel.follow {
+					return writeSection(flushWriter{out}, fmtter, s)
+				}
+				return writeSection(out, fmtter, s)
+			}
+
+			// openSections opens filename and returns its sections with all
+			// transformations applied, and a function closing the file
+			// once the sections have been read.
+			openSections := func(filename string) ([]section, func(), error) {
+				var sections []section
+				var err error
+				file := os.Stdin
+				if inline != nil {
+					if len(sel.ranges) > 0 {
+						return nil, nil, fmt.Errorf("--range cannot be combined with inline input")
+					}
+					sections, err = sel.streamSections(bytes.NewReader(inline), int64(len(inline)), "inline data")
+				} else if isURL(filename) {
+					sections, err = sel.urlSections(filename)
+				} else {
+					if filename != "-" {
+						file, err = os.OpenFile(filename, os.O_RDONLY, 0666)
+						if err != nil {
+							return nil, nil, fmt.Errorf("could not open file, reason: %w", err)
+						}
+					}
+					sections, err = sel.sections(file)
+				}
+				closeSections := func() {
+					for _, s := range sections {
+						if s.release != nil {
+							s.release()
+						}
+					}
+					if file != os.Stdin {
+						file.Close()
+					}
+				}
+				if err != nil {
+					closeSections()
+					return nil, nil, err
+				}
+
+				for i, s := range sections {
+					// The key is aligned to the selected bytes, so it is
+					// applied before they are padded or rearranged.
+					if xorKey != nil {
+						sections[i].r = &xorReader{r: s.r, key: xorKey}
+						s = sections[i]
+					}
+					if sel.pad != nil {
+						sections[i] = padSection(s, *sel.pad)
+						s = sections[i]
+					}
+					if bits {
+						sections[i] = bitSection(s, uint(bitOffset%8), bitSize, rightAligned)
+						s = sections[i]
+					}
+					if swap > 0 {
+						sections[i], err = swapSection(s, swap, opts.ZeroPad)
+						if err != nil {
+							closeSections()
+							return nil, nil, err
+						}
+						s = sections[i]
+					}
+					if c.Bool("reverse") {
+						sections[i], err = reversed(s, reverseLimit)
+						if err != nil {
+							closeSections()
+							return nil, nil, err
+						}
+						s = sections[i]
+					}
+					if repeat > 1 {
+						sections[i], err = repeated(s, repeat)
+						if err != nil {
+							closeSections()
+							return nil, nil, fmt.Errorf("could not read the section to repeat, reason: %w", err)
+						}
+					}
+				}
+				return sections, closeSections, nil
+			}
+
+			if c.Bool("combined") {
+				// Only the first file is opened up front, the others are
+				// opened once the previous one has been read.
+				sections, closeSections, err := openSections(filenames[0])
+				if err != nil {
+					return err
+				}
+				combined := &combinedReader{
+					open:      openSections,
+					filenames: filenames[1:],
+				}
+				combined.next(sections, closeSections)
+				defer combined.close()
+				// The length of the files opened later is not known yet.
+				length := int64(-1)
+				if len(filenames) == 1 {
+					length = totalLength(sections)
+				}
+				var offset int64
+				if len(sections) > 0 {
+					offset = sections[0].offset
+				}
+				err = emit(section{
+					byteRange: byteRange{offset: offset, size: -1},
+					r:         combined,
+					length:    length,
+				}, "")
+				if err != nil {
+					return err
+				}
 			} else {
-				in = file
+				for _, filename := range filenames {
+					sections, closeSections, err := openSections(filename)
+					if err != nil {
+						return err
+					}
+					for _, s := range sections {
+						err = emit(s, filename)
+						if err != nil {
+							break
+						}
+					}
+					closeSections()
+					if err != nil {
+						return err
+					}
+				}
 			}
 
-			err = fmtter(os.Stdout, in)
+			if mismatch {
+				return cli.Exit("checksum verification failed", 1)
+			}
+			err = out.Flush()
 			if err != nil {
-				return fmt.Errorf("an error occured during reading of the file: %w", err)
+				return err
+			}
+			// Digests of empty selections are valid results, which are
+			// reported like any other.
+			if selected == 0 && !info.Digest && !c.IsSet("verify") {
+				return cli.Exit("", exitEmpty)
 			}
 			return nil
 		},
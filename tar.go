@@ -0,0 +1,104 @@
+package main
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// findTarMember scans the tar archive read from file for the member called
+// name and returns a reader of its contents and its size.
+func findTarMember(file *os.File, name string) (io.Reader, int64, error) {
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, 0, fmt.Errorf("the tar archive has no member \"%s\"", name)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("could not read tar archive, reason: %w", err)
+		}
+		if path.Clean(header.Name) != path.Clean(name) {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			return nil, 0, fmt.Errorf("the tar member \"%s\" is not a regular file", name)
+		}
+		return tr, header.Size, nil
+	}
+}
+
+// tarSections returns the selection within the tar member s.tarMember of the
+// archive read from file. Offsets are relative to the start of the member.
+func (s selection) tarSections(file *os.File) ([]section, error) {
+	if len(s.ranges) > 0 {
+		return nil, fmt.Errorf("--range cannot be combined with --tar-member")
+	}
+	member, memberSize, err := findTarMember(file, s.tarMember)
+	if err != nil {
+		return nil, err
+	}
+	return s.streamSections(member, memberSize, fmt.Sprintf("member \"%s\"", s.tarMember))
+}
+
+// streamSections returns the selection from r, a stream of total bytes
+// described by name in error messages. Negative offsets are relative to its
+// end and the bytes in front of the offset are skipped by reading them.
+func (s selection) streamSections(r io.Reader, total int64, name string) ([]section, error) {
+	var err error
+	resolve := func(pos int64) (int64, error) {
+		if pos >= 0 {
+			return pos, nil
+		}
+		if -pos > total {
+			return 0, fmt.Errorf("offset of %d bytes from the end exceeds the size of %s of %d bytes", -pos, name, total)
+		}
+		return total + pos, nil
+	}
+	offset := s.offset
+	if s.fromEnd {
+		offset = -offset
+	}
+	offset, err = resolve(offset)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve offset, reason: %w", err)
+	}
+	if offset > total {
+		return nil, fmt.Errorf("offset 0x%X is beyond end of %s (size 0x%X)", offset, name, total)
+	}
+	size := s.size
+	if s.end != nil {
+		end, err := resolve(*s.end)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve end, reason: %w", err)
+		}
+		if end <= offset {
+			return nil, fmt.Errorf("end 0x%X must be greater than offset 0x%X", end, offset)
+		}
+		size = end - offset
+	}
+	if size == -1 {
+		size = total - offset
+	}
+	length := size
+	if offset+size > total {
+		err = s.beyondEnd(fmt.Errorf("range 0x%X+0x%X extends beyond end of %s (size 0x%X)", offset, size, name, total))
+		if err != nil {
+			return nil, err
+		}
+		length = total - offset
+	}
+
+	_, err = io.CopyN(ioutil.Discard, r, offset)
+	if err != nil {
+		return nil, fmt.Errorf("could not skip to offset 0x%X, reason: %w", offset, err)
+	}
+	return []section{{
+		byteRange: byteRange{offset: offset, size: size},
+		r:         io.LimitReader(r, length),
+		length:    length,
+	}}, nil
+}
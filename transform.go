@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// xorReader XORs all bytes read from r with key, which is repeated
+// cyclically.
+type xorReader struct {
+	r   io.Reader
+	key []byte
+	pos int
+}
+
+func (x *xorReader) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+	for i := range p[:n] {
+		p[i] ^= x.key[x.pos]
+		x.pos = (x.pos + 1) % len(x.key)
+	}
+	return n, err
+}
+
+// padReader reads from r and continues with fill bytes once r is exhausted
+// until remaining bytes have been read in total.
+type padReader struct {
+	r         io.Reader
+	remaining int64
+	fill      byte
+	eof       bool
+}
+
+func (p *padReader) Read(buf []byte) (int, error) {
+	if !p.eof {
+		n, err := p.r.Read(buf)
+		p.remaining -= int64(n)
+		if err != io.EOF {
+			return n, err
+		}
+		p.eof = true
+		if n > 0 {
+			return n, nil
+		}
+	}
+	if p.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(buf)) > p.remaining {
+		buf = buf[:p.remaining]
+	}
+	for i := range buf {
+		buf[i] = p.fill
+	}
+	p.remaining -= int64(len(buf))
+	return len(buf), nil
+}
+
+// reversed returns s with the order of its bytes reversed. The whole section
+// is buffered, which fails if it is larger than limit bytes.
+func reversed(s section, limit int64) (section, error) {
+	if s.length > limit {
+		return section{}, fmt.Errorf("cannot reverse 0x%X bytes, the limit is 0x%X bytes (see --reverse-limit)", s.length, limit)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(s.r, limit+1))
+	if err != nil {
+		return section{}, fmt.Errorf("could not read the section to reverse, reason: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return section{}, fmt.Errorf("cannot reverse more than 0x%X bytes (see --reverse-limit)", limit)
+	}
+	for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+	s.r = bytes.NewReader(data)
+	s.length = int64(len(data))
+	return s, nil
+}
+
+// bitReader reads a range of bits from r and assembles them into bytes, the
+// most significant bit first.
+type bitReader struct {
+	r io.ByteReader
+	// skip is the number of bits dropped from the start of r.
+	skip uint
+	// left is the number of bits of r still to be read.
+	left int64
+	// acc holds nacc pending bits in its least significant bits.
+	acc  uint
+	nacc uint
+}
+
+// newBitReader returns a reader yielding size bits of r starting at bit skip
+// of its first byte. Unless rightAligned is set, the bits start at the most
+// significant bit of the first byte and the last byte is padded with zero
+// bits. Otherwise the first byte is padded at the front such that the last
+// bit ends up in the least significant bit of the last byte.
+func newBitReader(r io.Reader, skip uint, size int64, rightAligned bool) *bitReader {
+	br := &bitReader{
+		r:    bufio.NewReader(r),
+		skip: skip,
+		left: size,
+	}
+	if rightAligned {
+		br.nacc = uint(-size & 7)
+	}
+	return br
+}
+
+func (b *bitReader) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		for b.nacc < 8 && b.left > 0 {
+			c, err := b.r.ReadByte()
+			if err == io.EOF {
+				// The input is shorter than requested, pad what is left.
+				b.left = 0
+				break
+			}
+			if err != nil {
+				return n, err
+			}
+			bits := 8 - b.skip
+			value := uint(c) & (1<<bits - 1)
+			b.skip = 0
+			if int64(bits) > b.left {
+				value >>= bits - uint(b.left)
+				bits = uint(b.left)
+			}
+			b.acc = b.acc<<bits | value
+			b.nacc += bits
+			b.left -= int64(bits)
+		}
+
+		if b.nacc >= 8 {
+			b.nacc -= 8
+			p[n] = byte(b.acc >> b.nacc)
+			b.acc &= 1<<b.nacc - 1
+		} else if b.nacc > 0 {
+			p[n] = byte(b.acc << (8 - b.nacc))
+			b.acc = 0
+			b.nacc = 0
+		} else {
+			return n, io.EOF
+		}
+		n++
+	}
+	return n, nil
+}
+
+// swapReader reverses the order of the bytes within every word of r.
+type swapReader struct {
+	r    io.Reader
+	word []byte
+	// pending are the swapped bytes of the current word not yet read.
+	pending []byte
+	// pad fills up an incomplete last word with zero bytes instead of
+	// failing.
+	pad   bool
+	total int64
+}
+
+func newSwapReader(r io.Reader, wordSize int, pad bool) *swapReader {
+	return &swapReader{
+		r:    bufio.NewReader(r),
+		word: make([]byte, wordSize),
+		pad:  pad,
+	}
+}
+
+func (s *swapReader) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		if len(s.pending) == 0 {
+			m, err := io.ReadFull(s.r, s.word)
+			s.total += int64(m)
+			if err == io.EOF {
+				return n, io.EOF
+			}
+			if err == io.ErrUnexpectedEOF {
+				if !s.pad {
+					return n, fmt.Errorf("input length must be a multiple of the word size %d to swap, got %d bytes", len(s.word), s.total)
+				}
+				for i := m; i < len(s.word); i++ {
+					s.word[i] = 0
+				}
+			} else if err != nil {
+				return n, err
+			}
+			for i, j := 0, len(s.word)-1; i < j; i, j = i+1, j-1 {
+				s.word[i], s.word[j] = s.word[j], s.word[i]
+			}
+			s.pending = s.word
+		}
+		m := copy(p[n:], s.pending)
+		s.pending = s.pending[m:]
+		n += m
+	}
+	return n, nil
+}
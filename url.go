@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// isURL reports whether name is an HTTP(S) URL rather than a file name.
+func isURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// urlSections fetches the selection from the resource at url. Only the
+// selected bytes are requested using a Range header, servers ignoring it are
+// handled by discarding the bytes in front of the offset.
+func (s selection) urlSections(url string) ([]section, error) {
+	if len(s.ranges) > 0 || s.fromEnd || s.offset < 0 || s.tarMember != "" || s.follow {
+		return nil, fmt.Errorf("URLs only support selecting by --offset, --size and --end")
+	}
+	offset := s.offset
+	size := s.size
+	if s.end != nil {
+		if *s.end <= offset {
+			return nil, fmt.Errorf("end 0x%X must be greater than offset 0x%X", *s.end, offset)
+		}
+		size = *s.end - offset
+	}
+	if size == 0 {
+		return []section{{byteRange: byteRange{offset: offset, size: 0}, r: strings.NewReader(""), length: 0}}, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if size > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
+	} else if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch %s, reason: %w", url, err)
+	}
+
+	length := resp.ContentLength
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The server ignored the range and sends everything.
+		_, err = io.CopyN(ioutil.Discard, resp.Body, offset)
+		if err == io.EOF {
+			resp.Body.Close()
+			return nil, fmt.Errorf("offset 0x%X is beyond end of %s", offset, url)
+		}
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("could not skip to offset 0x%X, reason: %w", offset, err)
+		}
+		if length >= 0 {
+			length -= offset
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return nil, fmt.Errorf("offset 0x%X is beyond end of %s", offset, url)
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not fetch %s, reason: %s", url, resp.Status)
+	}
+
+	if size != -1 && length >= 0 && length < size {
+		err = s.beyondEnd(fmt.Errorf("range 0x%X+0x%X extends beyond end of %s (size 0x%X)", offset, size, url, offset+length))
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	var r io.Reader = resp.Body
+	if size != -1 {
+		r = io.LimitReader(r, size)
+		if length < 0 || length > size {
+			length = size
+		}
+	}
+	return []section{{
+		byteRange: byteRange{offset: offset, size: size},
+		r:         r,
+		length:    length,
+		release:   resp.Body.Close,
+	}}, nil
+}